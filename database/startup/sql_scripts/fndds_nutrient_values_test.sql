@@ -1,28 +1,20 @@
--- fndds_nutrient_values_test.sql
-
-DROP TABLE IF EXISTS fndds_nutrient_values CASCADE;
-
-CREATE TABLE fndds_nutrient_values (
-    "Food code" numeric PRIMARY KEY,
-    "Main food description" varchar NOT NULL,
-    "WWEIA Category number" numeric NOT NULL,
-    "WWEIA Category description" varchar NOT NULL,
-    "Energy (kcal)" numeric NOT NULL,
-    "Protein (g)" numeric NOT NULL,
-    "Carbohydrate (g)" numeric NOT NULL,
-    "Sugars, total (g)" numeric NOT NULL,
-    "Potassium (mg)" numeric NOT NULL,
-    "Phosphorus (mg)" numeric NOT NULL
-);
-
--- 🍌 Banana
-INSERT INTO fndds_nutrient_values VALUES
-(1111, 'Banana', 5000, 'Fruits', 89, 1.1, 23, 12, 358, 22);
-
--- 🥦 Broccoli
-INSERT INTO fndds_nutrient_values VALUES
-(2222, 'Broccoli', 6000, 'Vegetables', 34, 2.8, 7, 2, 316, 66);
-
--- 🧈 Tofu
-INSERT INTO fndds_nutrient_values VALUES
-(3333, 'Tofu', 7000, 'Vegetarian Products', 76, 8.1, 1.9, 0.5, 118, 190);
+-- fndds_nutrient_values_test.sql
+-- Loads a few foods as the 2021-2023 release, run after the migrations
+
+DELETE FROM fndds_nutrient_values WHERE dataset_version = '2021-2023';
+
+INSERT INTO fndds_nutrient_values (dataset_version, "Food code", "Main food description", "WWEIA Category number", "WWEIA Category description", "Energy (kcal)", "Protein (g)", "Carbohydrate (g)", "Potassium (mg)", "Phosphorus (mg)") VALUES
+-- 🍌 Banana
+('2021-2023', 1111, 'Banana', 5000, 'Fruits', 89, 1.1, 23, 358, 22),
+-- 🥦 Broccoli
+('2021-2023', 2222, 'Broccoli', 6000, 'Vegetables', 34, 2.8, 7, 316, 66),
+-- 🧈 Tofu
+('2021-2023', 3333, 'Tofu', 7000, 'Vegetarian Products', 76, 8.1, 1.9, 118, 190);
+
+UPDATE fndds_nutrient_values
+SET description = to_tsvector('english', "Main food description")
+WHERE dataset_version = '2021-2023';
+
+INSERT INTO fndds_datasets (version, source_file, food_count, active)
+VALUES ('2021-2023', 'fndds_nutrient_values_test.sql', 3, true)
+ON CONFLICT (version) DO UPDATE SET active = true;
@@ -0,0 +1,12 @@
+-- fndds_portions_weights_test.sql
+-- Loads portions for the test foods as the 2021-2023 release, run after the
+-- migrations
+
+DELETE FROM fndds_portions_weights WHERE dataset_version = '2021-2023';
+
+INSERT INTO fndds_portions_weights (dataset_version, "Food code", "Seq num", "Portion description", "Portion weight (g)") VALUES
+-- 🍌 Banana
+('2021-2023', 1111, 1, '1 cup, sliced', 150),
+('2021-2023', 1111, 2, '1 medium (7" to 7-7/8" long)', 118),
+-- 🥦 Broccoli
+('2021-2023', 2222, 1, '1 cup, chopped', 91);
@@ -1,2 +0,0 @@
-ALTER TABLE fndds_nutrient_values
-ADD CONSTRAINT unique_food_code PRIMARY KEY ("Food code");
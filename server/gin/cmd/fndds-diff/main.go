@@ -0,0 +1,62 @@
+package main
+
+/*
+ * fndds-diff reports the food codes whose potassium or phosphorus changed by
+ * more than a threshold between two imported FNDDS releases
+ *
+ *   go run cmd/fndds-diff/main.go -from 2021-2023 -to 2023-2025 -threshold 10
+ */
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/fndds"
+)
+
+func main() {
+	from := flag.String("from", "", "older FNDDS release (required)")
+	to := flag.String("to", "", "newer FNDDS release (required)")
+	threshold := flag.Float64("threshold", 10, "report changes larger than this percent")
+	dbURL := flag.String("db", os.Getenv("DATABASE_URL"), "postgres connection string, defaults to $DATABASE_URL")
+	flag.Parse()
+
+	if *from == "" || *to == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *dbURL == "" {
+		log.Fatal("No database given, set DATABASE_URL or -db.")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, *dbURL)
+	if err != nil {
+		log.Fatalf("DB connection failed: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	diff, err := fndds.DiffVersions(conn, *from, *to, *threshold)
+	if err != nil {
+		log.Fatalf("Diff failed: %v", err)
+	}
+
+	fmt.Printf("K/P changes over %.1f%% from %s to %s: %d\n", *threshold, *from, *to, len(diff.Changed))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Food code\tDescription\tK (mg)\tK %\tP (mg)\tP %")
+	for _, c := range diff.Changed {
+		fmt.Fprintf(w, "%d\t%s\t%.0f -> %.0f\t%+.1f\t%.0f -> %.0f\t%+.1f\n",
+			c.FoodCode, c.Description,
+			c.From.Potassium, c.To.Potassium, c.PotassiumPct,
+			c.From.Phosphorus, c.To.Phosphorus, c.PhosphorusPct)
+	}
+	w.Flush()
+
+	fmt.Printf("\nAdded in %s: %d %v\n", *to, len(diff.Added), diff.Added)
+	fmt.Printf("Removed in %s: %d %v\n", *to, len(diff.Removed), diff.Removed)
+}
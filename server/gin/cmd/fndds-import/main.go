@@ -0,0 +1,92 @@
+package main
+
+/*
+ * fndds-import loads the USDA FNDDS nutrient values, and optionally the
+ * portions and weights, from local xlsx or csv files into postgres
+ *
+ *   go run cmd/fndds-import/main.go -nutrients fndds_data/2021-2023.xlsx \
+ *       -portions fndds_data/2021-2023-portions.xlsx -version 2021-2023
+ */
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/fndds"
+)
+
+func main() {
+	nutrientsPath := flag.String("nutrients", "", "FNDDS nutrient values .xlsx or .csv file (required)")
+	portionsPath := flag.String("portions", "", "FNDDS portions and weights .xlsx or .csv file")
+	version := flag.String("version", "2021-2023", "FNDDS release the files come from")
+	activate := flag.Bool("activate", true, "make this release the one searches use")
+	dbURL := flag.String("db", os.Getenv("DATABASE_URL"), "postgres connection string, defaults to $DATABASE_URL")
+	flag.Parse()
+
+	if *nutrientsPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *dbURL == "" {
+		log.Fatal("No database given, set DATABASE_URL or -db.")
+	}
+
+	// Read and validate every file before touching the database
+	nutrients, err := fndds.ReadFile(*nutrientsPath)
+	if err != nil {
+		log.Fatalf("%s: %v", *nutrientsPath, err)
+	}
+	if err := nutrients.Validate(fndds.NutrientColumns); err != nil {
+		log.Fatalf("%s: %v", *nutrientsPath, err)
+	}
+	var portions *fndds.Table
+	if *portionsPath != "" {
+		portions, err = fndds.ReadFile(*portionsPath)
+		if err != nil {
+			log.Fatalf("%s: %v", *portionsPath, err)
+		}
+		if err := portions.Validate(fndds.PortionColumns); err != nil {
+			log.Fatalf("%s: %v", *portionsPath, err)
+		}
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, *dbURL)
+	if err != nil {
+		log.Fatalf("DB connection failed: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// No-op once committed
+	defer tx.Rollback(ctx)
+
+	foodCount, err := fndds.ImportNutrientValues(ctx, tx, nutrients, *version)
+	if err != nil {
+		log.Fatalf("Importing nutrient values failed: %v", err)
+	}
+	log.Printf("Loaded %d foods.", foodCount)
+
+	if portions != nil {
+		portionCount, err := fndds.ImportPortions(ctx, tx, portions, *version)
+		if err != nil {
+			log.Fatalf("Importing portions failed: %v", err)
+		}
+		log.Printf("Loaded %d portions.", portionCount)
+	}
+
+	if err := fndds.RecordVersion(ctx, tx, *version, filepath.Base(*nutrientsPath), foodCount, *activate); err != nil {
+		log.Fatalf("Recording dataset version failed: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Fatalf("Commit failed: %v", err)
+	}
+	log.Printf("FNDDS %s import complete.", *version)
+}
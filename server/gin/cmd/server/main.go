@@ -3,19 +3,51 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/kimsh02/kay-phos/server/gin/internal/handlers"
+	"github.com/kimsh02/kay-phos/server/gin/internal/mailer"
+	"github.com/kimsh02/kay-phos/server/gin/internal/migrations"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
 	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
 	"github.com/kimsh02/kay-phos/server/gin/internal/router"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
 )
 
 func main() {
+	// Schema subcommand: main migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	// Role subcommand: main role <username> patient|clinician|admin
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		runRole(os.Args[2:])
+		return
+	}
+
+	// Refuse to start without a key to sign sessions with
+	keys, err := services.KeyRingFromEnv()
+	if err != nil {
+		log.Fatalf("Loading JWT keys failed: %v", err)
+	}
+	services.SetKeyRing(keys)
+
+	// Bring the schema up to date before the pool prepares statements
+	if err := migrateUp(context.Background()); err != nil {
+		log.Fatalf("Migrating database failed: %v", err)
+	}
+
 	// Initialize db connection pool
 
 	dbPool, err := repositories.NewDBConnectionPool()
@@ -35,6 +67,8 @@ func main() {
 	app := &handlers.App{
 		DB:        dbPool,
 		FnddsRepo: &repositories.Fndds{},
+		Mailer:    mailer.FromEnv(),
+		BaseURL:   os.Getenv("APP_URL"),
 	}
 	// Initialize router
 	r := router.NewRouter()
@@ -78,9 +112,91 @@ func main() {
 	<-shutdownChan
 	log.Println("Server shutting down...")
 
-	// do not trust any proxies
-	// r.SetTrustedProxies(nil)
-
 	// set server to release mode
 	// gin.SetMode(gin.ReleaseMode)
 }
+
+// migrateUp Applies pending migrations on a connection of its own, the pool
+// prepares statements against the tables they create
+func migrateUp(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	_, err = migrations.Up(ctx, conn)
+	return err
+}
+
+// runRole Handles `main role <username> <role>`, which is how the first admin
+// is made
+func runRole(args []string) {
+	if len(args) != 2 || !models.ValidRole(args[1]) {
+		log.Fatal("usage: main role <username> patient|clinician|admin")
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("DB connection failed: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	updated, err := repositories.UpdateUserRoleByName(conn, args[0], args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !updated {
+		log.Fatalf("no user named %q", args[0])
+	}
+	log.Printf("Role of %s set to %s.", args[0], args[1])
+}
+
+// runMigrate Handles `main migrate up|down [-steps n]|status`
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: main migrate up|down|status")
+	}
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert")
+	_ = fs.Parse(args[1:])
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("DB connection failed: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(ctx, conn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Applied %d migrations.", len(applied))
+	case "down":
+		reverted, err := migrations.Down(ctx, conn, *steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Reverted %d migrations.", len(reverted))
+	case "status":
+		statuses, err := migrations.Statuses(ctx, conn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Version\tName\tApplied")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		w.Flush()
+	default:
+		log.Fatalf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+}
@@ -0,0 +1,126 @@
+package fndds
+
+/*
+ * Compares the potassium and phosphorus of two FNDDS releases
+ */
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// FoodValues are the kidney relevant values of one food in one release
+type FoodValues struct {
+	Description string
+	Potassium   float64
+	Phosphorus  float64
+}
+
+// Change is a food whose potassium or phosphorus moved between releases
+type Change struct {
+	FoodCode      int
+	Description   string
+	From          FoodValues
+	To            FoodValues
+	PotassiumPct  float64
+	PhosphorusPct float64
+}
+
+// Diff is the difference between two releases
+type Diff struct {
+	Changed []Change
+	Added   []int
+	Removed []int
+}
+
+// DiffVersions Loads both releases and compares them, see CompareVersions
+func DiffVersions(db repositories.DBClient, from, to string, threshold float64) (*Diff, error) {
+	fromFoods, err := loadValues(db, from)
+	if err != nil {
+		return nil, err
+	}
+	toFoods, err := loadValues(db, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(fromFoods) == 0 {
+		return nil, fmt.Errorf("dataset version %q has no foods", from)
+	}
+	if len(toFoods) == 0 {
+		return nil, fmt.Errorf("dataset version %q has no foods", to)
+	}
+	return CompareVersions(fromFoods, toFoods, threshold), nil
+}
+
+// CompareVersions Lists the food codes whose potassium or phosphorus changed by
+// more than threshold percent, and the codes only one of the releases has
+func CompareVersions(from, to map[int]FoodValues, threshold float64) *Diff {
+	diff := &Diff{}
+	for code, old := range from {
+		cur, ok := to[code]
+		if !ok {
+			diff.Removed = append(diff.Removed, code)
+			continue
+		}
+		k := percentChange(old.Potassium, cur.Potassium)
+		p := percentChange(old.Phosphorus, cur.Phosphorus)
+		if math.Abs(k) > threshold || math.Abs(p) > threshold {
+			diff.Changed = append(diff.Changed, Change{
+				FoodCode:      code,
+				Description:   cur.Description,
+				From:          old,
+				To:            cur,
+				PotassiumPct:  k,
+				PhosphorusPct: p,
+			})
+		}
+	}
+	for code := range to {
+		if _, ok := from[code]; !ok {
+			diff.Added = append(diff.Added, code)
+		}
+	}
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].FoodCode < diff.Changed[j].FoodCode })
+	sort.Ints(diff.Added)
+	sort.Ints(diff.Removed)
+	return diff
+}
+
+// percentChange Is the change from old to cur in percent of old. A value that
+// appears from 0 counts as a 100% change
+func percentChange(old, cur float64) float64 {
+	if old == 0 {
+		if cur == 0 {
+			return 0
+		}
+		return 100
+	}
+	return (cur - old) / old * 100
+}
+
+func loadValues(db repositories.DBClient, version string) (map[int]FoodValues, error) {
+	rows, err := db.Query(context.Background(), `
+		SELECT "Food code", "Main food description", COALESCE("Potassium (mg)", 0), COALESCE("Phosphorus (mg)", 0)
+		FROM fndds_nutrient_values
+		WHERE dataset_version = $1;
+	`, version)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	foods := map[int]FoodValues{}
+	for rows.Next() {
+		var code int
+		var v FoodValues
+		if err := rows.Scan(&code, &v.Description, &v.Potassium, &v.Phosphorus); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		foods[code] = v
+	}
+	return foods, rows.Err()
+}
@@ -0,0 +1,31 @@
+package fndds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- CompareVersions(): threshold on K and P, added and removed food codes
+
+func TestCompareVersions(t *testing.T) {
+	from := map[int]FoodValues{
+		1111: {Description: "Banana", Potassium: 358, Phosphorus: 22},
+		2222: {Description: "Broccoli", Potassium: 316, Phosphorus: 66},
+		3333: {Description: "Tofu", Potassium: 118, Phosphorus: 190},
+	}
+	to := map[int]FoodValues{
+		1111: {Description: "Banana", Potassium: 370, Phosphorus: 22},
+		2222: {Description: "Broccoli", Potassium: 316, Phosphorus: 80},
+		4444: {Description: "Rice", Potassium: 35, Phosphorus: 43},
+	}
+
+	diff := CompareVersions(from, to, 10)
+
+	assert.Len(t, diff.Changed, 1)
+	assert.Equal(t, 2222, diff.Changed[0].FoodCode)
+	assert.InDelta(t, 21.2, diff.Changed[0].PhosphorusPct, 0.1)
+	assert.Equal(t, []int{4444}, diff.Added)
+	assert.Equal(t, []int{3333}, diff.Removed)
+}
@@ -0,0 +1,199 @@
+package fndds
+
+/*
+ * Loads FNDDS releases into postgres. Each release is stored next to the
+ * others under its dataset version, so meals computed from an older release
+ * keep their numbers
+ */
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// textColumns are the FNDDS columns that hold text, every other column is a
+// number
+var textColumns = map[string]bool{
+	"Main food description":      true,
+	"WWEIA Category description": true,
+	"Portion description":        true,
+}
+
+// ImportNutrientValues Replaces the rows of the given release in
+// fndds_nutrient_values with the table, then fills the tsvector description
+// column and makes sure the GIN and trigram indexes used by search and
+// autocomplete exist
+func ImportNutrientValues(ctx context.Context, tx pgx.Tx, t *Table, version string) (int64, error) {
+	if err := t.Validate(NutrientColumns); err != nil {
+		return 0, err
+	}
+	n, err := loadTable(ctx, tx, "fndds_nutrient_values", []string{"Food code"}, t, version)
+	if err != nil {
+		return 0, err
+	}
+
+	stmts := []string{
+		`ALTER TABLE fndds_nutrient_values ADD COLUMN IF NOT EXISTS description tsvector;`,
+		`UPDATE fndds_nutrient_values SET description = to_tsvector('english', "Main food description") WHERE dataset_version = $1;`,
+		`CREATE INDEX IF NOT EXISTS idx_gin_description ON fndds_nutrient_values USING gin (description);`,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm;`,
+		`CREATE INDEX IF NOT EXISTS idx_trgm_description ON fndds_nutrient_values USING gin ("Main food description" gin_trgm_ops);`,
+	}
+	for _, stmt := range stmts {
+		var args []any
+		if strings.Contains(stmt, "$1") {
+			args = append(args, version)
+		}
+		if _, err := tx.Exec(ctx, stmt, args...); err != nil {
+			return 0, fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return n, nil
+}
+
+// ImportPortions Replaces the rows of the given release in
+// fndds_portions_weights with the table
+func ImportPortions(ctx context.Context, tx pgx.Tx, t *Table, version string) (int64, error) {
+	if err := t.Validate(PortionColumns); err != nil {
+		return 0, err
+	}
+	n, err := loadTable(ctx, tx, "fndds_portions_weights", []string{"Food code", "Seq num"}, t, version)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_portions_food_code ON fndds_portions_weights (dataset_version, "Food code");`); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// RecordVersion Records which release was imported, and makes it the release
+// searches use when activate is set
+func RecordVersion(ctx context.Context, tx pgx.Tx, version, sourceFile string, foodCount int64, activate bool) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS fndds_datasets (
+			version     TEXT PRIMARY KEY,
+			source_file TEXT NOT NULL,
+			food_count  INTEGER NOT NULL,
+			imported_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			active      BOOLEAN NOT NULL DEFAULT false
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_fndds_datasets_active ON fndds_datasets (active) WHERE active;`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO fndds_datasets (version, source_file, food_count)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (version) DO UPDATE SET
+			source_file = EXCLUDED.source_file,
+			food_count = EXCLUDED.food_count,
+			imported_at = now();
+	`, version, sourceFile, foodCount)
+	if err != nil || !activate {
+		return err
+	}
+	return ActivateVersion(ctx, tx, version)
+}
+
+// ActivateVersion Makes the release the one searches default to
+func ActivateVersion(ctx context.Context, tx pgx.Tx, version string) error {
+	// Clear first, the unique index allows a single active row at any time
+	if _, err := tx.Exec(ctx, `UPDATE fndds_datasets SET active = false WHERE active AND version <> $1;`, version); err != nil {
+		return err
+	}
+	cmdTag, err := tx.Exec(ctx, `UPDATE fndds_datasets SET active = true WHERE version = $1;`, version)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("unknown dataset version %q", version)
+	}
+	return nil
+}
+
+// loadTable Creates the named table if needed, adds any columns the header
+// has that the table does not, then replaces the rows of the release
+func loadTable(ctx context.Context, tx pgx.Tx, name string, key []string, t *Table, version string) (int64, error) {
+	table := pgx.Identifier{name}.Sanitize()
+	pk := []string{"dataset_version"}
+	for _, col := range key {
+		pk = append(pk, pgx.Identifier{col}.Sanitize())
+	}
+
+	stmts := []string{
+		"CREATE TABLE IF NOT EXISTS " + table + " (dataset_version text NOT NULL, PRIMARY KEY (" + strings.Join(pk, ", ") + "));",
+	}
+	for _, col := range t.Header {
+		typ := "numeric"
+		if textColumns[col] {
+			typ = "varchar"
+		}
+		stmts = append(stmts, "ALTER TABLE "+table+" ADD COLUMN IF NOT EXISTS "+pgx.Identifier{col}.Sanitize()+" "+typ+";")
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return 0, fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE dataset_version = $1;", version); err != nil {
+		return 0, err
+	}
+
+	rows, err := convertRows(t, version)
+	if err != nil {
+		return 0, err
+	}
+	columns := append([]string{"dataset_version"}, t.Header...)
+	return tx.CopyFrom(ctx, pgx.Identifier{name}, columns, pgx.CopyFromRows(rows))
+}
+
+// convertRows Parses numeric cells, skips blank lines and prepends the release
+// to each row. The first row of the file is line 1 of the data, after the
+// header
+func convertRows(t *Table, version string) ([][]any, error) {
+	var rows [][]any
+	for line, record := range t.Rows {
+		if isBlank(record) {
+			continue
+		}
+		row := make([]any, len(t.Header)+1)
+		row[0] = version
+		for i, col := range t.Header {
+			var cell string
+			if i < len(record) {
+				cell = strings.TrimSpace(record[i])
+			}
+			switch {
+			case textColumns[col]:
+				row[i+1] = cell
+			case cell == "":
+				row[i+1] = nil
+			default:
+				v, err := strconv.ParseFloat(cell, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d, column %q: %q is not a number", line+1, col, cell)
+				}
+				row[i+1] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,276 @@
+package fndds
+
+/*
+ * Reads the USDA FNDDS "At A Glance" spreadsheets from a local xlsx or csv
+ * file into a header and rows of strings
+ */
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Table is a spreadsheet with the title lines above the header removed
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Column returns the index of the named column, or -1
+func (t *Table) Column(name string) int {
+	for i, h := range t.Header {
+		if h == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// NutrientColumns are the nutrient values columns the server queries
+var NutrientColumns = []string{
+	"Food code",
+	"Main food description",
+	"WWEIA Category description",
+	"Energy (kcal)",
+	"Protein (g)",
+	"Carbohydrate (g)",
+	"Potassium (mg)",
+	"Phosphorus (mg)",
+}
+
+// PortionColumns are the portions and weights columns the server queries
+var PortionColumns = []string{
+	"Food code",
+	"Seq num",
+	"Portion description",
+	"Portion weight (g)",
+}
+
+// ReadFile Reads a csv or xlsx file and drops the title lines above the row
+// that starts with "Food code"
+func ReadFile(path string) (*Table, error) {
+	var records [][]string
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err = readCSV(path)
+	case ".xlsx":
+		records, err = readXLSX(path)
+	default:
+		return nil, fmt.Errorf("unsupported file type %q, expected .csv or .xlsx", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, record := range records {
+		if len(record) > 0 && strings.TrimSpace(record[0]) == "Food code" {
+			header := make([]string, len(record))
+			for j, h := range record {
+				header[j] = strings.TrimSpace(h)
+			}
+			// Trailing empty header cells are formatting, not columns
+			for len(header) > 0 && header[len(header)-1] == "" {
+				header = header[:len(header)-1]
+			}
+			return &Table{Header: header, Rows: records[i+1:]}, nil
+		}
+	}
+	return nil, errors.New("no header row starting with \"Food code\" found")
+}
+
+// Validate Checks that every required column is present
+func (t *Table) Validate(required []string) error {
+	var missing []string
+	for _, name := range required {
+		if t.Column(name) == -1 {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing columns: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func readCSV(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	// Title lines have fewer fields than the data
+	r.FieldsPerRecord = -1
+	return r.ReadAll()
+}
+
+// xlsx parts, only the elements the importer needs
+
+type sharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// firstSheetPath Finds the part of the first sheet in the workbook's order,
+// which need not be sheet1.xml once sheets were renamed or moved
+func firstSheetPath(zr *zip.Reader) (string, error) {
+	var wb workbook
+	if err := decodeZipXML(zr, "xl/workbook.xml", &wb); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", errors.New("workbook has no sheets")
+	}
+	var rels relationships
+	if err := decodeZipXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	first := wb.Sheets[0]
+	for _, rel := range rels.Items {
+		if rel.ID != first.RID {
+			continue
+		}
+		// Targets are relative to xl/ unless they start at the root
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("sheet %q has no part", first.Name)
+}
+
+// readXLSX Reads the first worksheet of an xlsx workbook
+func readXLSX(path string) ([][]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var strs sharedStrings
+	if err := decodeZipXML(&zr.Reader, "xl/sharedStrings.xml", &strs); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	shared := make([]string, len(strs.Items))
+	for i, si := range strs.Items {
+		if len(si.Runs) == 0 {
+			shared[i] = si.Text
+			continue
+		}
+		var b strings.Builder
+		for _, r := range si.Runs {
+			b.WriteString(r.Text)
+		}
+		shared[i] = b.String()
+	}
+
+	sheetPath, err := firstSheetPath(&zr.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var sheet worksheet
+	if err := decodeZipXML(&zr.Reader, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	records := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var record []string
+		for _, cell := range row.Cells {
+			col := columnIndex(cell.Ref)
+			if col < 0 {
+				col = len(record)
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err != nil || idx < 0 || idx >= len(shared) {
+					return nil, fmt.Errorf("cell %s: bad shared string index %q", cell.Ref, cell.Value)
+				}
+				record[col] = shared[idx]
+			case "inlineStr":
+				record[col] = cell.Inline.Text
+			default:
+				record[col] = cell.Value
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(data, v)
+}
+
+// columnIndex Converts the letters of a cell reference like "AB12" to a zero
+// based column index, or -1 if there are none
+func columnIndex(ref string) int {
+	idx := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		idx = idx*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return idx - 1
+}
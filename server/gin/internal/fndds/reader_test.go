@@ -0,0 +1,118 @@
+package fndds
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- ReadFile(): csv and xlsx parsing with the title lines dropped, the first
+//  sheet of a workbook is found whatever its part is named
+//- Validate(): reports missing columns
+//- convertRows(): numeric parsing and blank line skipping
+
+const nutrientHeader = `Food code,Main food description,WWEIA Category number,WWEIA Category description,Energy (kcal),Protein (g),Carbohydrate (g),Potassium (mg),Phosphorus (mg)`
+
+func TestReadFile_CSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fndds.csv")
+	data := "2021-2023 FNDDS At A Glance - FNDDS Nutrient Values\n\n" +
+		nutrientHeader + "\n" +
+		"1111,Banana,5000,Fruits,89,1.1,23,358,22\n" +
+		",,,,,,,,\n"
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	table, err := ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, table.Validate(NutrientColumns))
+	assert.Equal(t, "Food code", table.Header[0])
+
+	rows, err := convertRows(table, "2021-2023")
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "2021-2023", rows[0][0])
+	assert.Equal(t, 1111.0, rows[0][1])
+	assert.Equal(t, "Banana", rows[0][2])
+	assert.Equal(t, 358.0, rows[0][8])
+}
+
+// writeXLSX Writes a workbook of the given parts, listing sheets in order
+// with their part under xl/
+func writeXLSX(t *testing.T, parts map[string]string, sheets ...string) string {
+	path := filepath.Join(t.TempDir(), "fndds.xlsx")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	zw := zip.NewWriter(f)
+
+	workbook := `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`
+	rels := `<Relationships>`
+	for i, part := range sheets {
+		id := fmt.Sprintf("rId%d", i+5)
+		workbook += fmt.Sprintf(`<sheet name="Sheet %d" sheetId="%d" r:id="%s"/>`, i, i+1, id)
+		rels += fmt.Sprintf(`<Relationship Id="%s" Target="%s"/>`, id, part)
+	}
+	parts["xl/workbook.xml"] = workbook + `</sheets></workbook>`
+	parts["xl/_rels/workbook.xml.rels"] = rels + `</Relationships>`
+	for name, data := range parts {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, _ = w.Write([]byte(data))
+	}
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, f.Close())
+	return path
+}
+
+const portionsSheet = `<worksheet><sheetData>
+	<row r="1"><c r="A1" t="inlineStr"><is><t>Portions and Weights</t></is></c></row>
+	<row r="2"><c r="A2" t="s"><v>0</v></c><c r="B2" t="s"><v>1</v></c><c r="C2" t="s"><v>2</v></c><c r="D2" t="s"><v>3</v></c></row>
+	<row r="3"><c r="A3"><v>1111</v></c><c r="B3"><v>1</v></c><c r="C3" t="s"><v>4</v></c><c r="D3"><v>150</v></c></row>
+</sheetData></worksheet>`
+
+const portionsStrings = `<sst><si><t>Food code</t></si><si><t>Seq num</t></si><si><r><t>Portion </t></r><r><t>description</t></r></si><si><t>Portion weight (g)</t></si><si><t>1 cup, sliced</t></si></sst>`
+
+func TestReadFile_XLSX(t *testing.T) {
+	path := writeXLSX(t, map[string]string{
+		"xl/sharedStrings.xml":     portionsStrings,
+		"xl/worksheets/sheet1.xml": portionsSheet,
+	}, "worksheets/sheet1.xml")
+
+	table, err := ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, table.Validate(PortionColumns))
+	assert.Equal(t, [][]string{{"1111", "1", "1 cup, sliced", "150"}}, table.Rows)
+}
+
+func TestReadFile_XLSXReorderedSheets(t *testing.T) {
+	// The data sheet was moved first, sheet1.xml is now a notes sheet
+	path := writeXLSX(t, map[string]string{
+		"xl/sharedStrings.xml":     portionsStrings,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Notes</t></is></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": portionsSheet,
+	}, "/xl/worksheets/sheet2.xml", "worksheets/sheet1.xml")
+
+	table, err := ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"1111", "1", "1 cup, sliced", "150"}}, table.Rows)
+}
+
+func TestValidate_MissingColumns(t *testing.T) {
+	table := &Table{Header: []string{"Food code", "Main food description"}}
+
+	err := table.Validate(NutrientColumns)
+	assert.ErrorContains(t, err, "Potassium (mg)")
+}
+
+func TestConvertRows_BadNumber(t *testing.T) {
+	table := &Table{
+		Header: []string{"Food code", "Main food description", "Potassium (mg)"},
+		Rows:   [][]string{{"1111", "Banana", "lots"}},
+	}
+
+	_, err := convertRows(table, "2021-2023")
+	assert.ErrorContains(t, err, "line 1")
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/middleware"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+/*
+ * handler for account settings
+ */
+
+// currentUser Loads the logged in user, responding with an error if it can't
+func (a *App) currentUser(c *gin.Context) (*models.User, *models.Claims, bool) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return nil, nil, false
+	}
+	user := &models.User{UserID: userID}
+	if err := repositories.GetUser(a.DB, user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, nil, false
+	}
+	return user, claims, true
+}
+
+// PUT /dashboard/api/account/password
+func (a *App) ChangePassword(c *gin.Context) {
+	var req models.PasswordChange
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, claims, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	if err := user.CheckPassword(req.CurrentPassword); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	user.InputPassword = req.NewPassword
+	if err := user.SetHashedPassword(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := repositories.UpdatePassword(a.DB, user.UserID, user.HashedPassword); err != nil {
+		log.Printf("❌ UpdatePassword failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	// Log out every other device, the old password may have leaked
+	sessionID, _ := uuid.Parse(claims.SessionID)
+	if err := repositories.RevokeOtherSessions(a.DB, user.UserID, sessionID); err != nil {
+		log.Printf("❌ RevokeOtherSessions failed: %v", err)
+	}
+	_ = a.audit(c, models.AuditPasswordChange, user.UserID, "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
+// PUT /dashboard/api/account
+func (a *App) UpdateAccount(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	var req models.AccountUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Email first, a taken address must not leave the names half saved
+	resp := gin.H{}
+	if req.Email != nil {
+		err := repositories.UpdateUserEmail(a.DB, userID, *req.Email)
+		if errors.Is(err, repositories.ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email is already in use"})
+			return
+		}
+		if err != nil {
+			log.Printf("❌ UpdateUserEmail failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+			return
+		}
+		resp["email"] = *req.Email
+	}
+
+	updated, err := repositories.UpdateUserNames(a.DB, userID, req.FirstName, req.LastName)
+	if err != nil {
+		log.Printf("❌ UpdateUserNames failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	resp["firstName"], resp["lastName"] = req.FirstName, req.LastName
+	_ = a.audit(c, models.AuditUserUpdate, userID, "")
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /dashboard/api/account
+func (a *App) DeleteAccount(c *gin.Context) {
+	var req models.AccountDeletion
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password is required to delete the account"})
+		return
+	}
+
+	user, _, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	if err := user.CheckPassword(req.Password); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	// Meals and sessions go with the user, which also ends the current token
+	if _, err := repositories.DeleteUser(a.DB, user.UserID); err != nil {
+		log.Printf("❌ DeleteUser failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+	log.Printf("🗑️ Deleted account %s", user.UserID)
+	// Events don't reference users, so this outlives the account
+	_ = a.audit(c, models.AuditUserDelete, user.UserID, "")
+
+	middleware.ClearSessionCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//✅ What This Tests
+//- ChangePassword(): re-verifies the current password, revokes other sessions
+//- UpdateAccount(): validates and saves names, 409 for an email in use
+//- DeleteAccount(): requires the password, deletes the user, clears cookies
+
+func setupAccountRouter(mockDB *testutils.MockDB, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: userID.String(), SessionID: uuid.New().String()})
+		c.Next()
+	})
+	router.PUT("/api/account", app.UpdateAccount)
+	router.PUT("/api/account/password", app.ChangePassword)
+	router.DELETE("/api/account", app.DeleteAccount)
+	return router
+}
+
+func mockUserRow(userID uuid.UUID, password string) *testutils.MockRow {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	return &testutils.MockRow{Values: []any{"Ada", "Lovelace", "ada", userID, string(hashed)}}
+}
+
+func TestChangePassword_Success(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockUserRow(userID, "oldpass"))
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+	router := setupAccountRouter(mockDB, userID)
+
+	body := `{"currentPassword": "oldpass", "newPassword": "newpass"}`
+	req, _ := http.NewRequest("PUT", "/api/account/password", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// Password update and revocation of the other sessions
+	mockDB.AssertNumberOfCalls(t, "Exec", 2)
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockUserRow(userID, "oldpass"))
+	router := setupAccountRouter(mockDB, userID)
+
+	body := `{"currentPassword": "guess", "newPassword": "newpass"}`
+	req, _ := http.NewRequest("PUT", "/api/account/password", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateAccount(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Exec", mock.Anything, mock.Anything, []any{userID, "Grace", "Hopper"}).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+	router := setupAccountRouter(mockDB, userID)
+
+	req, _ := http.NewRequest("PUT", "/api/account", bytes.NewBufferString(`{"firstName": " Grace ", "lastName": "Hopper"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("PUT", "/api/account", bytes.NewBufferString(`{"firstName": "", "lastName": "Hopper"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateAccount_EmailTaken(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	taken := &pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email"}
+	mockDB.On("Exec", mock.Anything, mock.Anything, []any{userID, "ada@example.com"}).Return(pgconn.CommandTag{}, taken)
+	router := setupAccountRouter(mockDB, userID)
+
+	body := `{"firstName": "Ada", "lastName": "Lovelace", "email": " ada@example.com "}`
+	req, _ := http.NewRequest("PUT", "/api/account", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	// Names are left alone
+	mockDB.AssertNumberOfCalls(t, "Exec", 1)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockUserRow(userID, "secret"))
+	mockDB.On("Exec", mock.Anything, mock.Anything, []any{userID}).Return(pgconn.NewCommandTag("DELETE 1"), nil)
+	router := setupAccountRouter(mockDB, userID)
+
+	req, _ := http.NewRequest("DELETE", "/api/account", bytes.NewBufferString(`{"password": "wrong"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	req, _ = http.NewRequest("DELETE", "/api/account", bytes.NewBufferString(`{"password": "secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockDB.AssertNumberOfCalls(t, "Exec", 1)
+	for _, cookie := range w.Result().Cookies() {
+		assert.Empty(t, cookie.Value)
+	}
+}
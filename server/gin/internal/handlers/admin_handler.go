@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+/*
+ * handler for administrators
+ */
+
+// PUT /admin/api/users/:id/role
+func (a *App) SetUserRole(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	var req models.RoleChange
+	if err := c.ShouldBindJSON(&req); err != nil || !models.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be patient, clinician or admin"})
+		return
+	}
+	// Keeps the last admin from locking everyone out
+	if userID.String() == claims.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't change your own role"})
+		return
+	}
+
+	updated, err := repositories.UpdateUserRole(a.DB, userID, req.Role)
+	if err != nil {
+		log.Printf("❌ UpdateUserRole failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	_ = a.audit(c, models.AuditUserRoleChange, userID, req.Role)
+	log.Printf("🛡️ Role of %s changed to %s", userID, req.Role)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role changed"})
+}
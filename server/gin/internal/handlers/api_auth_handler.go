@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+)
+
+/*
+ * handler for API clients such as the mobile app. Tokens go in the response
+ * body and come back in an Authorization: Bearer header, no cookies are set
+ */
+
+// POST /api/auth/login
+func (a *App) APILogin(c *gin.Context, user *models.User) {
+	if !a.checkLogin(c, user) {
+		return
+	}
+	// Users with two-factor authentication finish at /api/auth/login/totp
+	mfaToken, ok := a.mfaChallenge(c, user)
+	if !ok {
+		return
+	}
+	if mfaToken != "" {
+		c.JSON(http.StatusOK, gin.H{"mfaRequired": true, "mfaToken": mfaToken})
+		return
+	}
+
+	tokens, ok := a.startSession(c, user)
+	if !ok {
+		return
+	}
+	respondWithTokens(c, tokens)
+}
+
+// POST /api/auth/login/totp
+func (a *App) APILoginTOTP(c *gin.Context) {
+	var req models.TOTPCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	user, ok := a.checkLoginTOTP(c, req.MFAToken, &req)
+	if !ok {
+		return
+	}
+	tokens, ok := a.startSession(c, user)
+	if !ok {
+		return
+	}
+	respondWithTokens(c, tokens)
+}
+
+// POST /api/auth/refresh
+func (a *App) APIRefresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	tokens, err := services.RefreshSession(a.DB, req.RefreshToken)
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("❌ Refreshing session failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session."})
+		return
+	}
+	// A parallel refresh with the same token already got the new refresh
+	// token, the client must use that one
+	if tokens.RefreshToken == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Session was already refreshed."})
+		return
+	}
+	respondWithTokens(c, tokens)
+}
+
+// POST /api/auth/logout
+func (a *App) APILogout(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+	if _, err := repositories.RevokeSession(a.DB, sessionID); err != nil {
+		log.Println("❌ Revoking session failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out."})
+		return
+	}
+	if userID, err := uuid.Parse(claims.UserID); err == nil {
+		_ = a.audit(c, models.AuditLogout, userID, sessionID.String())
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out."})
+}
+
+// GET /.well-known/jwks.json
+func JWKS(c *gin.Context) {
+	keys := []services.JWK{}
+	if r := services.CurrentKeyRing(); r != nil {
+		keys = r.PublicKeys()
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// respondWithTokens Sends the session tokens in the OAuth 2 token response
+// shape most client libraries understand
+func respondWithTokens(c *gin.Context, tokens *services.SessionTokens) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"tokenType":    "Bearer",
+		"expiresIn":    int(services.AccessTokenTTL.Seconds()),
+		"sessionId":    tokens.SessionID,
+	})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//✅ What This Tests
+//- APILogin(): tokens in the body and no cookies, or an mfaToken for TOTP users
+//- APIRefresh(): rotates the refresh token from the body, 409 on a lost race
+//- APILogout(): revokes the session of the bearer token
+
+func setupAPIAuthRouter(t *testing.T, mockDB *testutils.MockDB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	testutils.UseTestKeyRing(t)
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.POST("/api/auth/login", MakeUserHandler(app.APILogin))
+	router.POST("/api/auth/refresh", app.APIRefresh)
+	router.POST("/api/auth/logout", func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.New().String(), SessionID: uuid.New().String()})
+		c.Next()
+	}, app.APILogout)
+	return router
+}
+
+func mockLoginUser(mockDB *testutils.MockDB, totp *testutils.MockRow) {
+	mockLoginThrottle(mockDB)
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("testpass"), bcrypt.MinCost)
+	mockDB.On("QueryRow", mock.Anything, "user_select_username_query", mock.Anything).Return(&testutils.MockRow{
+		Values: []any{"Test", "User", "testuser", uuid.New(), string(hashed)},
+	})
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM user_totp"), mock.Anything).Return(totp)
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+}
+
+func TestAPILogin_Success(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockLoginUser(mockDB, &testutils.MockRow{Err: pgx.ErrNoRows})
+	router := setupAPIAuthRouter(t, mockDB)
+
+	payload, _ := json.Marshal(models.User{UserName: "testuser", InputPassword: "testpass"})
+	req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp["accessToken"])
+	assert.NotEmpty(t, resp["refreshToken"])
+	assert.Equal(t, "Bearer", resp["tokenType"])
+	assert.Equal(t, 900.0, resp["expiresIn"])
+}
+
+func TestAPILogin_TOTPRequired(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockLoginUser(mockDB, &testutils.MockRow{Values: []any{totpTestSecret, true, int64(0)}})
+	router := setupAPIAuthRouter(t, mockDB)
+
+	payload, _ := json.Marshal(models.User{UserName: "testuser", InputPassword: "testpass"})
+	req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, true, resp["mfaRequired"])
+	assert.NotEmpty(t, resp["mfaToken"])
+	assert.Nil(t, resp["accessToken"])
+}
+
+func TestAPIRefresh_Success(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{
+		Values: []any{uuid.New(), uuid.New(), time.Now(), time.Now().Add(time.Hour)},
+	})
+	router := setupAPIAuthRouter(t, mockDB)
+
+	req, _ := http.NewRequest("POST", "/api/auth/refresh", bytes.NewBufferString(`{"refreshToken": "old-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp["refreshToken"])
+	assert.NotEqual(t, "old-token", resp["refreshToken"])
+}
+
+func TestAPIRefresh_AlreadyRotated(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	// Rotated by a parallel request a moment ago
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("UPDATE user_sessions"), mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("previous_token_hash = $1"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{uuid.New(), uuid.New(), time.Now(), time.Now().Add(time.Hour)},
+	})
+	router := setupAPIAuthRouter(t, mockDB)
+
+	req, _ := http.NewRequest("POST", "/api/auth/refresh", bytes.NewBufferString(`{"refreshToken": "old-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestAPIRefresh_Invalid(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	router := setupAPIAuthRouter(t, mockDB)
+
+	req, _ := http.NewRequest("POST", "/api/auth/refresh", bytes.NewBufferString(`{"refreshToken": "unknown"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAPILogout(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Exec", mock.Anything, sqlContaining("revoked_at"), mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+	router := setupAPIAuthRouter(t, mockDB)
+
+	req, _ := http.NewRequest("POST", "/api/auth/logout", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockDB.AssertNumberOfCalls(t, "Exec", 1)
+}
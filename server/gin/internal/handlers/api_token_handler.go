@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+)
+
+/*
+ * handler for personal access tokens
+ */
+
+// GET /dashboard/api/tokens
+func (a *App) ListAPITokens(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	tokens, err := repositories.ListAPITokens(a.DB, userID)
+	if err != nil {
+		log.Printf("❌ ListAPITokens failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load API tokens"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens, "scopes": models.APITokenScopes})
+}
+
+// POST /dashboard/api/tokens
+func (a *App) CreateAPIToken(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	var req models.APITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, value, err := services.CreateAPIToken(a.DB, userID, &req)
+	if err != nil {
+		log.Printf("❌ CreateAPIToken failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+		return
+	}
+	log.Printf("🔑 Created API token %s for %s", t.ID, userID)
+	_ = a.audit(c, models.AuditAPITokenCreate, userID, t.ID.String())
+
+	// The token value is only ever shown here
+	c.JSON(http.StatusCreated, gin.H{"token": value, "apiToken": t})
+}
+
+// DELETE /dashboard/api/tokens/:id
+func (a *App) RevokeAPIToken(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	revoked, err := repositories.RevokeAPIToken(a.DB, userID, tokenID)
+	if err != nil {
+		log.Printf("❌ RevokeAPIToken failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API token"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		return
+	}
+	_ = a.audit(c, models.AuditAPITokenRevoke, userID, tokenID.String())
+	c.JSON(http.StatusOK, gin.H{"message": "API token revoked"})
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- CreateAPIToken(): returns the token value once, rejects unknown scopes
+//- RevokeAPIToken(): 404 for a token the user doesn't have
+
+func setupAPITokenRouter(mockDB *testutils.MockDB, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: userID.String(), SessionID: uuid.New().String()})
+		c.Next()
+	})
+	router.POST("/api/tokens", app.CreateAPIToken)
+	router.DELETE("/api/tokens/:id", app.RevokeAPIToken)
+	return router
+}
+
+func TestCreateAPIToken_Success(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Values: []any{time.Now()}})
+	router := setupAPITokenRouter(mockDB, uuid.New())
+
+	body := `{"name": " export script ", "scopes": ["meals:read", "meals:read"], "expiresInDays": 30}`
+	req, _ := http.NewRequest("POST", "/api/tokens", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp struct {
+		Token    string          `json:"token"`
+		APIToken models.APIToken `json:"apiToken"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, strings.HasPrefix(resp.Token, services.APITokenPrefix))
+	assert.Equal(t, "export script", resp.APIToken.Name)
+	assert.Equal(t, []string{models.ScopeMealsRead}, resp.APIToken.Scopes)
+	assert.NotNil(t, resp.APIToken.ExpiresAt)
+
+	// Only the hash of the token is stored
+	args := mockDB.Calls[0].Arguments.Get(2).([]any)
+	assert.Equal(t, services.HashToken(resp.Token), args[3])
+}
+
+func TestCreateAPIToken_UnknownScope(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupAPITokenRouter(mockDB, uuid.New())
+
+	body := `{"name": "script", "scopes": ["admin"]}`
+	req, _ := http.NewRequest("POST", "/api/tokens", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockDB.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRevokeAPIToken_NotFound(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+	router := setupAPITokenRouter(mockDB, uuid.New())
+
+	req, _ := http.NewRequest("DELETE", "/api/tokens/"+uuid.NewString(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- ListAuditEvents(): filters are validated, the query itself is audited,
+//  CSV exports page through every event, client supplied values cannot
+//  become spreadsheet formulas
+
+func setupAuditRouter(mockDB *testutils.MockDB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.NewString(), SessionID: uuid.NewString()})
+		c.Next()
+	})
+	router.GET("/admin/api/audit-events", app.ListAuditEvents)
+	return router
+}
+
+func auditRow(id int64, subjectID uuid.UUID) []any {
+	return []any{id, nil, &subjectID, models.AuditMealDelete, "42", "10.0.0.1", "curl", time.Now()}
+}
+
+func TestListAuditEvents_InvalidFilter(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupAuditRouter(mockDB)
+
+	req, _ := http.NewRequest("GET", "/admin/api/audit-events?subject=nope", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockDB.AssertNotCalled(t, "Query", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListAuditEvents_JSON(t *testing.T) {
+	subjectID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Query", mock.Anything, sqlContaining("FROM audit_events"), mock.Anything).Return(&testutils.ResultRows{
+		Rows: [][]any{auditRow(7, subjectID)},
+	}, nil)
+	router := setupAuditRouter(mockDB)
+
+	req, _ := http.NewRequest("GET", "/admin/api/audit-events?subject="+subjectID.String()+"&limit=1&to=2025-01-31", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"next":7`)
+	// A whole day to includes that day
+	mockDB.AssertCalled(t, "Query", mock.Anything, sqlContaining("FROM audit_events"), mock.MatchedBy(func(args []any) bool {
+		to := args[4].(*time.Time)
+		return *args[1].(*uuid.UUID) == subjectID && to.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) && args[6] == 1
+	}))
+	mockDB.AssertCalled(t, "QueryRow", mock.Anything, sqlContaining("INSERT INTO audit_events"), mock.MatchedBy(func(args []any) bool {
+		return args[2] == models.AuditLogRead && *args[1].(*uuid.UUID) == subjectID
+	}))
+}
+
+func TestListAuditEvents_CSV(t *testing.T) {
+	subjectID := uuid.New()
+	page := make([][]any, maxAuditPageSize)
+	for i := range page {
+		page[i] = auditRow(int64(maxAuditPageSize+1-i), subjectID)
+	}
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	// The second page starts below the last ID of the first
+	mockDB.On("Query", mock.Anything, sqlContaining("FROM audit_events"), mock.MatchedBy(func(args []any) bool {
+		return args[5] == int64(0)
+	})).Return(&testutils.ResultRows{Rows: page}, nil)
+	mockDB.On("Query", mock.Anything, sqlContaining("FROM audit_events"), mock.MatchedBy(func(args []any) bool {
+		return args[5] == int64(2)
+	})).Return(&testutils.ResultRows{Rows: [][]any{auditRow(1, subjectID)}}, nil)
+	router := setupAuditRouter(mockDB)
+
+	req, _ := http.NewRequest("GET", "/admin/api/audit-events?format=csv", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, maxAuditPageSize+2)
+	assert.Equal(t, "id,created_at,actor_id,subject_id,action,resource_id,ip,user_agent", lines[0])
+	assert.True(t, strings.HasPrefix(lines[len(lines)-1], "1,"))
+	mockDB.AssertNumberOfCalls(t, "Query", 2)
+}
+
+func TestListAuditEvents_CSVEscapesFormulas(t *testing.T) {
+	subjectID := uuid.New()
+	row := auditRow(1, subjectID)
+	row[4] = "-1+2"
+	row[6] = `=HYPERLINK("http://evil.example","x")`
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Query", mock.Anything, sqlContaining("FROM audit_events"), mock.Anything).Return(&testutils.ResultRows{Rows: [][]any{row}}, nil)
+	router := setupAuditRouter(mockDB)
+
+	req, _ := http.NewRequest("GET", "/admin/api/audit-events?format=csv", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "'-1+2", records[1][5])
+	assert.Equal(t, `'=HYPERLINK("http://evil.example","x")`, records[1][7])
+}
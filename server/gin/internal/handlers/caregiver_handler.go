@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+)
+
+/*
+ * handler for caregivers, who patients invite to log meals for them. A
+ * caregiver acts for a patient by sending the X-Patient-ID header, see
+ * middleware.PatientContextMiddleware
+ */
+
+// GET /dashboard/api/caregivers
+func (a *App) ListCaregivers(c *gin.Context) {
+	a.listPatientLinks(c, models.LinkCaregiver, "caregivers")
+}
+
+// POST /dashboard/api/caregivers
+func (a *App) InviteCaregiver(c *gin.Context) {
+	a.inviteMember(c, models.LinkCaregiver, "")
+}
+
+// DELETE /dashboard/api/caregivers/:id
+func (a *App) RemoveCaregiver(c *gin.Context) {
+	a.revokeLink(c, models.LinkCaregiver)
+}
+
+// GET /dashboard/api/caregiving/invitations
+func (a *App) ListCaregivingInvitations(c *gin.Context) {
+	a.listMemberLinks(c, models.LinkCaregiver, "invitations", false)
+}
+
+// POST /dashboard/api/caregiving/invitations/:id/accept
+func (a *App) AcceptCaregivingInvitation(c *gin.Context) {
+	a.acceptLink(c, models.LinkCaregiver)
+}
+
+// DELETE /dashboard/api/caregiving/:id
+func (a *App) StopCaregiving(c *gin.Context) {
+	a.revokeLink(c, models.LinkCaregiver)
+}
+
+// GET /dashboard/api/caregiving/patients
+func (a *App) ListCaregivingPatients(c *gin.Context) {
+	a.listMemberLinks(c, models.LinkCaregiver, "patients", true)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+)
+
+/*
+ * handler for clinicians, who patients invite to read their meal history
+ */
+
+// GET /dashboard/api/clinicians
+func (a *App) ListClinicians(c *gin.Context) {
+	a.listPatientLinks(c, models.LinkClinician, "clinicians")
+}
+
+// POST /dashboard/api/clinicians
+func (a *App) InviteClinician(c *gin.Context) {
+	a.inviteMember(c, models.LinkClinician, models.RoleClinician)
+}
+
+// DELETE /dashboard/api/clinicians/:id
+func (a *App) RemoveClinician(c *gin.Context) {
+	a.revokeLink(c, models.LinkClinician)
+}
+
+// GET /clinician/api/invitations
+func (a *App) ListClinicianInvitations(c *gin.Context) {
+	a.listMemberLinks(c, models.LinkClinician, "invitations", false)
+}
+
+// POST /clinician/api/invitations/:id/accept
+func (a *App) AcceptClinicianInvitation(c *gin.Context) {
+	a.acceptLink(c, models.LinkClinician)
+}
+
+// DELETE /clinician/api/invitations/:id
+func (a *App) DeclineClinicianInvitation(c *gin.Context) {
+	a.revokeLink(c, models.LinkClinician)
+}
+
+// GET /clinician/api/patients
+func (a *App) ListPatients(c *gin.Context) {
+	a.listMemberLinks(c, models.LinkClinician, "patients", true)
+}
+
+// GET /clinician/api/patients/:id/meals?type=history
+//
+// Pages through the patient's meals with the same filters and cursor as the
+// patient's own listing
+func (a *App) GetPatientMeals(c *gin.Context) {
+	patientID, ok := a.linkedPatient(c, models.LinkClinician)
+	if !ok {
+		return
+	}
+	mealType := c.DefaultQuery("type", "history")
+	if mealType != "history" && mealType != "favorite" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal type"})
+		return
+	}
+	filter, err := parseMealFilter(c, mealType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := a.audit(c, models.AuditPatientMealsRead, patientID, mealType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meals"})
+		return
+	}
+
+	page, err := a.mealPage(patientID, filter)
+	if err != nil {
+		log.Printf("❌ ListMeals failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meals"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GET /clinician/api/patients/:id/nutrient-history?start=2025-01-01&end=2025-01-31
+func (a *App) GetPatientNutrientHistory(c *gin.Context) {
+	patientID, ok := a.linkedPatient(c, models.LinkClinician)
+	if !ok {
+		return
+	}
+	if err := a.audit(c, models.AuditPatientHistoryRead, patientID, c.Query("start")+"/"+c.Query("end")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch nutrient history"})
+		return
+	}
+	a.respondNutrientHistory(c, patientID)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- InviteClinician(): only accounts with the clinician role can be invited
+//- GetPatientMeals(): unlinked patients are not found, reads are audited and
+//  paged with a cursor
+//- SetUserRole(): admins can't change their own role
+
+func setupClinicianRouter(mockDB *testutils.MockDB, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: userID.String(), SessionID: uuid.New().String()})
+		c.Next()
+	})
+	router.POST("/api/clinicians", app.InviteClinician)
+	router.GET("/clinician/api/patients/:id/meals", app.GetPatientMeals)
+	router.PUT("/admin/api/users/:id/role", app.SetUserRole)
+	return router
+}
+
+func TestInviteClinician_Success(t *testing.T) {
+	clinicianID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_username_query", mock.Anything).Return(&testutils.MockRow{
+		Values: []any{"Grace", "Hopper", "drhopper", clinicianID, "hash", "", models.RoleClinician},
+	})
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("INSERT INTO patient_links"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{time.Now()},
+	})
+	testutils.MockAuditLog(mockDB)
+	router := setupClinicianRouter(mockDB, uuid.New())
+
+	req, _ := http.NewRequest("POST", "/api/clinicians", bytes.NewBufferString(`{"user": "drhopper"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"username":"drhopper"`)
+	mockDB.AssertCalled(t, "QueryRow", mock.Anything, sqlContaining("INSERT INTO audit_events"), mock.Anything)
+}
+
+func TestInviteClinician_NotAClinician(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_username_query", mock.Anything).Return(&testutils.MockRow{
+		Values: []any{"Ada", "Lovelace", "ada", uuid.New(), "hash", "", models.RolePatient},
+	})
+	router := setupClinicianRouter(mockDB, uuid.New())
+
+	req, _ := http.NewRequest("POST", "/api/clinicians", bytes.NewBufferString(`{"user": "ada"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetPatientMeals_NotLinked(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM patient_links"), mock.Anything).Return(&testutils.MockRow{Values: []any{false}})
+	router := setupClinicianRouter(mockDB, uuid.New())
+
+	req, _ := http.NewRequest("GET", "/clinician/api/patients/"+uuid.NewString()+"/meals", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockDB.AssertNotCalled(t, "Query", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetPatientMeals_Linked(t *testing.T) {
+	patientID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM patient_links"), mock.Anything).Return(&testutils.MockRow{Values: []any{true}})
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Query", mock.Anything, sqlContaining("FROM meals"), mock.Anything).Return(&testutils.ResultRows{
+		Rows: [][]any{
+			{2, "Lunch", time.Now(), []models.Ingredient{}, "2021-2023"},
+			{1, "Breakfast", time.Now().Add(-time.Hour), []models.Ingredient{}, "2021-2023"},
+		},
+	}, nil)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("COUNT(*)"), mock.Anything).Return(&testutils.MockRow{Values: []any{40}})
+	router := setupClinicianRouter(mockDB, uuid.New())
+
+	req, _ := http.NewRequest("GET", "/clinician/api/patients/"+patientID.String()+"/meals?limit=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page models.MealPage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Meals, 1)
+	assert.Equal(t, "Lunch", page.Meals[0].MealName)
+	assert.Equal(t, 40, page.Total)
+	assert.NotEmpty(t, page.NextCursor)
+	mockDB.AssertCalled(t, "Query", mock.Anything, sqlContaining("FROM meals"), mock.MatchedBy(func(args []any) bool {
+		return args[0] == patientID && args[1] == "history" && args[10] == 2
+	}))
+	mockDB.AssertCalled(t, "QueryRow", mock.Anything, sqlContaining("INSERT INTO audit_events"), mock.MatchedBy(func(args []any) bool {
+		return args[2] == models.AuditPatientMealsRead && *args[1].(*uuid.UUID) == patientID
+	}))
+}
+
+func TestSetUserRole_Self(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	router := setupClinicianRouter(mockDB, userID)
+
+	req, _ := http.NewRequest("PUT", "/admin/api/users/"+userID.String()+"/role", bytes.NewBufferString(`{"role": "patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"github.com/kimsh02/kay-phos/server/gin/internal/mailer"
 	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
 )
 
@@ -11,4 +12,8 @@ import (
 type App struct {
 	DB        repositories.DBClient
 	FnddsRepo repositories.FnddsRepo
+	Mailer    mailer.Mailer
+	// BaseURL is prepended to links in emails, e.g. https://kayphos.com.
+	// Empty uses the host of the request
+	BaseURL string
 }
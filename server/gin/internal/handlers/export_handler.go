@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+)
+
+/*
+ * handler for users downloading all of their data
+ */
+
+// GET /dashboard/api/export
+func (a *App) ExportUserData(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	export, err := services.NewExport(a.DB, userID)
+	if err != nil {
+		log.Printf("❌ NewExport failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+		return
+	}
+	_ = a.audit(c, models.AuditUserExport, userID, "")
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="`+export.FileName()+`"`)
+	c.Status(http.StatusOK)
+	if err := export.Write(c.Writer); err != nil {
+		// The status is already sent, the client gets a broken zip
+		log.Printf("❌ Writing export for %s failed: %v", userID, err)
+		return
+	}
+	log.Printf("📦 Exported data of %s", userID)
+}
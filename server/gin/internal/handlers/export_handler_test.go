@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- ExportUserData(): the zip has JSON and CSV of the profile, targets, meals
+//  and recipes with ingredients and daily totals, and never the password hash.
+//  Text that starts like a formula is quoted in the CSVs
+
+func TestExportUserData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := uuid.New()
+	mealTime := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_userid_query", mock.Anything).Return(&testutils.MockRow{
+		Values: []any{"Ada", "=Lovelace", "ada", userID, "$2a$10$secrethash", "ada@example.com", models.RolePatient},
+	})
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM nutrient_targets"), mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	// Meals and days are read again for each file that lists them
+	for i := 0; i < 2; i++ {
+		mockDB.On("Query", mock.Anything, sqlContaining("GROUP BY DATE(time)"), mock.Anything).Return(&testutils.ResultRows{
+			Rows: [][]any{{mealTime, 410.5, 120.0, 8.0, 300.0}},
+		}, nil).Once()
+	}
+	for i := 0; i < 3; i++ {
+		mockDB.On("Query", mock.Anything, sqlContaining("FROM meals"), mock.Anything).Return(&testutils.ResultRows{
+			Rows: [][]any{{7, "Lunch", mealTime, "history", []models.Ingredient{{Name: "Banana", FoodCode: 63107010, Grams: 100, Potassium: 358}}, map[string]float64{"potassium": 358}, "2021-2023", nil, models.MealSlotLunch}},
+		}, nil).Once()
+	}
+
+	for i := 0; i < 3; i++ {
+		mockDB.On("Query", mock.Anything, sqlContaining("FROM recipes"), mock.Anything).Return(&testutils.ResultRows{
+			Rows: [][]any{{3, "Banana rice", 4.0, 800.0, []models.Ingredient{{Name: "Banana", FoodCode: 63107010, Grams: 200, Potassium: 716}, {Name: "@SUM(A1)", Grams: 10}}, mealTime, mealTime}},
+		}, nil).Once()
+	}
+
+	app := &App{DB: mockDB}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: userID.String(), SessionID: uuid.NewString()})
+		c.Next()
+	})
+	router.GET("/api/export", app.ExportUserData)
+
+	req, _ := http.NewRequest("GET", "/api/export", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	z, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range z.File {
+		r, _ := f.Open()
+		b, _ := io.ReadAll(r)
+		files[f.Name] = string(b)
+	}
+	for _, name := range []string{"profile.json", "profile.csv", "targets.json", "targets.csv", "meals.json", "meals.csv", "meal_ingredients.csv", "recipes.json", "recipes.csv", "recipe_ingredients.csv", "daily_totals.json", "daily_totals.csv"} {
+		assert.Contains(t, files, name)
+	}
+	assert.Contains(t, files["profile.json"], `"email": "ada@example.com"`)
+	for name, content := range files {
+		assert.NotContains(t, content, "secrethash", name)
+	}
+	assert.Contains(t, files["meals.json"], `"totals":{"potassium":358}`)
+	assert.Contains(t, files["meals.csv"], "7,history,Lunch,2025-03-04T12:00:00Z,lunch,358,")
+	assert.Contains(t, files["meal_ingredients.csv"], "Banana,63107010")
+	assert.Contains(t, files["recipes.json"], `"potassium":179,`)
+	assert.Contains(t, files["recipes.csv"], "3,Banana rice,4,800,716,")
+	assert.Contains(t, files["recipe_ingredients.csv"], "3,Banana rice,Banana,63107010,,0,200,716")
+	assert.Contains(t, files["profile.csv"], ",Ada,'=Lovelace,")
+	assert.Contains(t, files["recipe_ingredients.csv"], "3,Banana rice,'@SUM(A1),")
+	assert.Contains(t, files["daily_totals.csv"], "2025-03-04,410.5,120,8,300,false")
+	mockDB.AssertNumberOfCalls(t, "Query", 8)
+}
@@ -3,9 +3,10 @@ package handlers
 import (
 	"context"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
 	"math"
 	"net/http"
-	"strings"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,6 +17,11 @@ func (a *App) CalculateIntake(c *gin.Context) {
 		SelectedFoods []struct {
 			IngredientName string  `json:"ingredientName"`
 			WeightGrams    float64 `json:"weightGrams"`
+			// A food code with a household portion can be sent instead of
+			// a name and weight
+			FoodCode int     `json:"foodCode"`
+			Portion  string  `json:"portion"`
+			Quantity float64 `json:"quantity"`
 		} `json:"selectedFoods"`
 	}
 
@@ -24,64 +30,68 @@ func (a *App) CalculateIntake(c *gin.Context) {
 		return
 	}
 
-	var totalK, totalP, totalCar, totalC, totalPro float64
+	var ingredients []models.Ingredient
 	var breakdown []gin.H
 
 	for _, food := range req.SelectedFoods {
-		if strings.TrimSpace(food.IngredientName) == "" || food.WeightGrams <= 0 {
-			continue
-		}
-
-		items, err := a.FnddsRepo.FnddsQuery(a.DB, food.IngredientName)
-		if err != nil || items == nil || len(*items) == 0 {
-			// fallback: try first word (e.g., "lemon" from "lemon juice")
-			words := strings.Fields(food.IngredientName)
-			if len(words) > 0 {
-				items, err = a.FnddsRepo.FnddsQuery(a.DB, words[0])
-			}
-		}
-
-		if err != nil || items == nil || len(*items) == 0 {
+		ing, err := services.ResolveFood(a.DB, a.FnddsRepo, models.Ingredient{
+			Name:     food.IngredientName,
+			FoodCode: food.FoodCode,
+			Portion:  food.Portion,
+			Quantity: food.Quantity,
+			Grams:    food.WeightGrams,
+		})
+		if err != nil {
 			// skip bad food
 			continue
 		}
-
-		best := (*items)[0]
-		k := (best.Potassium / 100) * food.WeightGrams
-		p := (best.Phosphorus / 100) * food.WeightGrams
-		calories := (best.Calories / 100) * food.WeightGrams
-		protein := (best.Protein / 100) * food.WeightGrams
-		carbs := (best.Carbs / 100) * food.WeightGrams
-
-		totalK += k
-		totalP += p
-		totalC += calories
-		totalPro += protein
-		totalCar += carbs
+		ingredients = append(ingredients, ing)
 
 		breakdown = append(breakdown, gin.H{
-			"ingredientName": food.IngredientName,
-			"weightGrams":    food.WeightGrams,
-			"potassium":      math.Round(k),
-			"phosphorus":     math.Round(p),
-			"calories":       math.Round(calories),
-			"protein":        math.Round(protein),
-			"carbs":          math.Round(carbs),
+			"ingredientName": ing.Name,
+			"weightGrams":    ing.Grams,
+			"potassium":      math.Round(ing.Potassium),
+			"phosphorus":     math.Round(ing.Phosphorus),
+			"calories":       math.Round(ing.Calories),
+			"protein":        math.Round(ing.Protein),
+			"carbs":          math.Round(ing.Carbs),
 		})
 	}
 
+	totals := models.SumIngredients(ingredients)
 	c.JSON(http.StatusOK, gin.H{
 		"breakdown": breakdown,
 		"totals": gin.H{
-			"potassium":  math.Round(totalK),
-			"phosphorus": math.Round(totalP),
-			"calories":   math.Round(totalC),
-			"protein":    math.Round(totalPro),
-			"carbs":      math.Round(totalCar),
+			"potassium":  math.Round(totals["potassium"]),
+			"phosphorus": math.Round(totals["phosphorus"]),
+			"calories":   math.Round(totals["calories"]),
+			"protein":    math.Round(totals["protein"]),
+			"carbs":      math.Round(totals["carbs"]),
 		},
 	})
 }
 
+// GetFoodPortions Handler for GET /dashboard/api/foods/:code/portions
+func (a *App) GetFoodPortions(c *gin.Context) {
+	foodCode, err := strconv.Atoi(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid food code"})
+		return
+	}
+
+	portions, err := a.FnddsRepo.FnddsPortions(a.DB, foodCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB error"})
+		return
+	}
+	if len(portions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No portions found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"foodCode": foodCode, "portions": portions})
+}
+
 // SearchFood Handler for GET /dashboard/search-food
 func (a *App) SearchFood(c *gin.Context) {
 	query := c.Query("q")
@@ -90,7 +100,14 @@ func (a *App) SearchFood(c *gin.Context) {
 		return
 	}
 
-	results, err := a.FnddsRepo.FnddsQuery(a.DB, query)
+	// Search the active FNDDS release unless another is asked for
+	var results *[]models.FnddsFoodItem
+	var err error
+	if version := c.Query("version"); version != "" {
+		results, err = a.FnddsRepo.FnddsSearch(a.DB, query, version)
+	} else {
+		results, err = a.FnddsRepo.FnddsQuery(a.DB, query)
+	}
 	if err != nil || results == nil || len(*results) == 0 {
 		c.JSON(http.StatusOK, gin.H{"results": []models.FnddsFoodItem{}})
 		return
@@ -111,6 +128,7 @@ func (a *App) AutocompleteSuggestions(c *gin.Context) {
 	rows, err := a.DB.Query(context.Background(), `
 		SELECT DISTINCT "Main food description" FROM fndds_nutrient_values
 		WHERE "Main food description" ILIKE $1
+		  AND dataset_version = (SELECT version FROM fndds_datasets WHERE active)
 		LIMIT 10;`, prefix+"%")
 
 	if err != nil {
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
@@ -139,6 +140,33 @@ func TestSearchFood_Mocked(t *testing.T) {
 	assert.Equal(t, "Tofu", res["results"][0].Description)
 }
 
+func TestSearchFood_Version(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(repositories.MockFnddsRepo)
+	mockData := []models.FnddsFoodItem{
+		{FoodCode: 9876, Description: "Tofu", Potassium: 120, Phosphorus: 185, DatasetVersion: "2019-2020"},
+	}
+	mockRepo.On("FnddsSearch", mock.Anything, "tofu", "2019-2020").Return(&mockData, nil)
+
+	app := &App{FnddsRepo: mockRepo}
+
+	router := gin.New()
+	router.GET("/dashboard/search-food", app.SearchFood)
+
+	req, _ := http.NewRequest("GET", "/dashboard/search-food?q=tofu&version=2019-2020", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+
+	var res map[string][]models.FnddsFoodItem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res["results"], 1)
+	assert.Equal(t, "2019-2020", res["results"][0].DatasetVersion)
+	mockRepo.AssertNotCalled(t, "FnddsQuery", mock.Anything, mock.Anything)
+}
+
 func TestAutocompleteSuggestions_Mocked(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -210,7 +238,9 @@ func TestGetLoggedMeals_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
 	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(new(testutils.MockRows), nil)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Values: []any{0}})
 
 	app := &App{DB: mockDB}
 
@@ -233,6 +263,7 @@ func TestGetNutrientHistory_Success(t *testing.T) {
 
 	mockDB := new(testutils.MockDB)
 	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(new(testutils.MockRows), nil)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
 
 	app := &App{DB: mockDB}
 
@@ -251,6 +282,45 @@ func TestGetNutrientHistory_Success(t *testing.T) {
 	assert.Equal(t, 200, w.Code)
 }
 
+func TestGetNutrientHistory_BySlot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Query", mock.Anything, sqlContaining("FROM slots"), mock.Anything).Return(&testutils.ResultRows{
+		Rows: [][]any{{day, 1500.0, 600.0, 40.0, 1800.0, map[string]repositories.SlotNutrientTotals{
+			models.MealSlotDinner: {Potassium: 1100, Phosphorous: 350},
+			"other":               {Potassium: 400, Phosphorous: 250},
+		}}},
+	}, nil)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+
+	app := &App{DB: mockDB}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.New().String()})
+		c.Next()
+	})
+	router.GET("/dashboard/nutrient-history", app.GetNutrientHistory)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/dashboard/nutrient-history?start=2025-01-01&end=2025-01-31&bySlot=true", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"dinner":{"potassiumTotal":1100,"phosphorousTotal":350}`)
+	mockDB.AssertCalled(t, "Query", mock.Anything, sqlContaining("FROM slots"), mock.MatchedBy(func(args []any) bool {
+		return args[3] == "" && args[4] == true
+	}))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/dashboard/nutrient-history?start=2025-01-01&end=2025-01-31&slot=brunch", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
+
 func TestGetCurrentUserInfo_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -277,7 +347,7 @@ func TestInvalidPath_Returns401(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
-	router.NoRoute(InvalidPath)
+	router.NoRoute((&App{}).InvalidPath)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/not-a-real-page", nil)
@@ -396,3 +466,70 @@ func TestCreateUser_DBInsertFails(t *testing.T) {
 
 	assert.Equal(t, 400, w.Code)
 }
+
+func TestGetFoodPortions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(repositories.MockFnddsRepo)
+	mockRepo.On("FnddsPortions", mock.Anything, 1111).Return([]models.FnddsPortion{
+		{FoodCode: 1111, SeqNum: 1, Description: "1 cup, sliced", Grams: 150},
+	}, nil)
+	mockRepo.On("FnddsPortions", mock.Anything, 4444).Return([]models.FnddsPortion{}, nil)
+
+	app := &App{FnddsRepo: mockRepo}
+
+	router := gin.New()
+	router.GET("/dashboard/api/foods/:code/portions", app.GetFoodPortions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/dashboard/api/foods/1111/portions", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var res struct {
+		Portions []models.FnddsPortion `json:"portions"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Portions, 1)
+	assert.Equal(t, 150.0, res.Portions[0].Grams)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/dashboard/api/foods/4444/portions", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestCalculateIntake_Portion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(repositories.MockFnddsRepo)
+	mockRepo.On("FnddsLookup", mock.Anything, []int{1111}).Return(map[int]models.FnddsFoodItem{
+		1111: {FoodCode: 1111, Description: "Banana", Potassium: 358, Phosphorus: 22, Calories: 89},
+	}, nil)
+	mockRepo.On("FnddsPortions", mock.Anything, 1111).Return([]models.FnddsPortion{
+		{FoodCode: 1111, SeqNum: 1, Description: "1 cup, sliced", Grams: 150},
+		{FoodCode: 1111, SeqNum: 2, Description: "1 medium", Grams: 118},
+	}, nil)
+
+	app := &App{FnddsRepo: mockRepo}
+
+	router := gin.New()
+	router.POST("/calculate-intake", app.CalculateIntake)
+
+	// 2 cups of banana is 300 g
+	body := []byte(`{"selectedFoods":[{"foodCode":1111,"portion":"1 cup","quantity":2}]}`)
+	req, _ := http.NewRequest("POST", "/calculate-intake", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	totals := response["totals"].(map[string]interface{})
+	assert.Equal(t, 1074.0, totals["potassium"])
+	breakdown := response["breakdown"].([]interface{})
+	assert.Equal(t, 300.0, breakdown[0].(map[string]interface{})["weightGrams"])
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+)
+
+/*
+ * handler for importing meal history logged in other apps
+ */
+
+// maxImportBytes caps the size of an import file
+const maxImportBytes = 5 << 20
+
+// POST /dashboard/api/import?commit=true&skipUnmatched=true&tz=America/Phoenix
+//
+// The CSV is the body, or the file field of a multipart form. Without commit
+// it only previews what would be imported
+func (a *App) ImportMeals(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+	enteredBy, err := uuid.Parse(claims.Actor())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	loc := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown time zone"})
+			return
+		}
+	}
+
+	file, err := importFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing import file"})
+		return
+	}
+	defer file.Close()
+
+	format, rows, err := services.ParseImport(file, loc)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file is larger than 5 MB"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Can't read import file: " + err.Error()})
+		return
+	}
+	preview, err := services.ResolveImport(a.DB, a.FnddsRepo, format, rows)
+	if err != nil {
+		log.Printf("❌ ResolveImport failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to match foods"})
+		return
+	}
+
+	if c.Query("commit") != "true" {
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+	if len(preview.Unmatched) > 0 && c.Query("skipUnmatched") != "true" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Some rows can't be imported, fix them or import with skipUnmatched=true",
+			"preview": preview,
+		})
+		return
+	}
+	if len(preview.Meals) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to import"})
+		return
+	}
+
+	imported, err := repositories.InsertLoggedMeals(a.DB, userID, enteredBy, preview.Meals)
+	if err != nil {
+		log.Printf("❌ InsertLoggedMeals failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import meals"})
+		return
+	}
+	_ = a.audit(c, models.AuditMealImport, userID, strconv.FormatInt(imported, 10))
+	preview.Committed = true
+
+	c.JSON(http.StatusCreated, preview)
+}
+
+// importFile Returns the uploaded file of a multipart form, or else the body
+func importFile(c *gin.Context) (io.ReadCloser, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		return c.Request.Body, nil
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	return header.Open()
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- ImportMeals(): previews without saving, refuses to commit unmatched rows
+//  unless told to skip them, saves every meal in one statement
+
+const importCSV = "Date,Meal Name,Food,Grams\n" +
+	"2025-01-02,Lunch,Banana,100\n" +
+	"2025-01-02,Lunch,Unobtainium,10\n"
+
+func setupImportRouter(mockDB *testutils.MockDB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	banana := []models.FnddsFoodItem{{FoodCode: 63107010, Description: "Banana, raw", Potassium: 358}}
+	mockRepo := new(repositories.MockFnddsRepo)
+	mockRepo.On("FnddsQuery", mock.Anything, "Banana").Return(&banana, nil)
+	mockRepo.On("FnddsQuery", mock.Anything, "Unobtainium").Return((*[]models.FnddsFoodItem)(nil), nil)
+	app := &App{DB: mockDB, FnddsRepo: mockRepo}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.NewString(), SessionID: uuid.NewString()})
+		c.Next()
+	})
+	router.POST("/api/import", app.ImportMeals)
+	return router
+}
+
+func TestImportMeals_Preview(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupImportRouter(mockDB)
+
+	req, _ := http.NewRequest("POST", "/api/import", strings.NewReader(importCSV))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"description":"Banana, raw"`)
+	assert.Contains(t, w.Body.String(), `"error":"no matching food"`)
+	assert.Contains(t, w.Body.String(), `"committed":false`)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportMeals_CommitWithUnmatched(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupImportRouter(mockDB)
+
+	req, _ := http.NewRequest("POST", "/api/import?commit=true", strings.NewReader(importCSV))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportMeals_CommitMultipart(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Exec", mock.Anything, sqlContaining("jsonb_to_recordset"), mock.Anything).Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+	router := setupImportRouter(mockDB)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "diary.csv")
+	part.Write([]byte(importCSV))
+	form.Close()
+	req, _ := http.NewRequest("POST", "/api/import?commit=true&skipUnmatched=true", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"committed":true`)
+	mockDB.AssertCalled(t, "Exec", mock.Anything, sqlContaining("jsonb_to_recordset"), mock.MatchedBy(func(args []any) bool {
+		return strings.Contains(args[2].(string), `"meal_name":"Lunch"`) && !strings.Contains(args[2].(string), "Unobtainium")
+	}))
+}
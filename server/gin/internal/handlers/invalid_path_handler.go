@@ -11,10 +11,10 @@ import (
  * Handler for invalid paths
  */
 
-func InvalidPath(c *gin.Context) {
+func (a *App) InvalidPath(c *gin.Context) {
 	// Call token middleware
 	// If user is not logged in, redirect to log in
-	middleware.ValidateTokenMiddleware()(c)
+	middleware.ValidateTokenMiddleware(a.DB)(c)
 	// Else, stay on current page
 	if !c.IsAborted() {
 		// log.Println("Redirect from invalid path.")
@@ -1,14 +1,20 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
 	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
 )
 
 type mealEntryRequest struct {
@@ -32,34 +38,67 @@ func (app *App) InsertMealHistory(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
 		return
 	}
+	// A caregiver may be logging the meal for the user
+	enteredBy, err := uuid.Parse(claims.Actor())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
 
 	// Grouped meal support (newer AI/manual flows)
 	var grouped models.MealGroup
 	if err := c.ShouldBindJSON(&grouped); err == nil && grouped.MealName != "" && len(grouped.Ingredients) > 0 {
 		log.Printf("📥 Received grouped meal: %s (%s)", grouped.MealName, grouped.MealType)
 
+		if grouped.MealType != "favorite" && grouped.MealType != "history" {
+			log.Println("❌ Unknown meal type:", grouped.MealType)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal type"})
+			return
+		}
+		if !validateMealSlot(c, grouped.MealType, grouped.Slot) {
+			return
+		}
+
+		ingredients, version, discrepancies, ok := app.computeIngredients(c, grouped.Ingredients)
+		if !ok {
+			return
+		}
+		grouped.Ingredients, grouped.FnddsVersion = ingredients, version
+
 		switch grouped.MealType {
 		case "favorite":
-			if err := repositories.InsertCustomMeal(app.DB, userID, grouped.MealName, grouped.Time, grouped.Ingredients); err != nil {
+			if err := repositories.InsertCustomMeal(app.DB, userID, enteredBy, grouped.MealName, grouped.Time, grouped.Ingredients, grouped.FnddsVersion); err != nil {
 				log.Printf("❌ InsertCustomMeal failed: %v", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save favorite meal"})
 				return
 			}
-			c.JSON(http.StatusCreated, gin.H{"message": "Favorite meal saved"})
+			_ = app.audit(c, models.AuditMealCreate, userID, grouped.MealType)
+			res := gin.H{"message": "Favorite meal saved"}
+			if len(discrepancies) > 0 {
+				res["discrepancies"] = discrepancies
+			}
+			c.JSON(http.StatusCreated, res)
 			return
 
 		case "history":
-			if err := repositories.InsertLoggedMeal(app.DB, userID, grouped.MealName, grouped.Time, grouped.Ingredients); err != nil {
+			if err := repositories.InsertLoggedMeal(app.DB, userID, enteredBy, grouped.MealName, grouped.Time, grouped.Slot, grouped.Ingredients, grouped.FnddsVersion); err != nil {
 				log.Printf("❌ InsertLoggedMeal failed: %v", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log meal"})
 				return
 			}
-			c.JSON(http.StatusCreated, gin.H{"message": "Meal logged to history"})
-			return
-
-		default:
-			log.Println("❌ Unknown meal type:", grouped.MealType)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal type"})
+			_ = app.audit(c, models.AuditMealCreate, userID, grouped.MealType)
+			res := gin.H{"message": "Meal logged to history"}
+			if len(discrepancies) > 0 {
+				res["discrepancies"] = discrepancies
+			}
+			usage, err := app.targetUsage(userID, grouped.Time.Format("2006-01-02"), models.SumIngredients(grouped.Ingredients))
+			if err != nil {
+				log.Printf("⚠️ Failed to compare meal against targets: %v", err)
+			}
+			if usage != nil {
+				res["targets"] = usage
+			}
+			c.JSON(http.StatusCreated, res)
 			return
 		}
 	}
@@ -71,6 +110,58 @@ func (app *App) InsertMealHistory(c *gin.Context) {
 	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal format"})
 }
 
+// validateMealSlot Checks the slot of a meal, which only logged meals may
+// have. Writes the error response and returns false when it is invalid
+func validateMealSlot(c *gin.Context, mealType, slot string) bool {
+	if !models.ValidMealSlot(slot) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal slot", "slots": models.MealSlots})
+		return false
+	}
+	if slot != "" && mealType != "history" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only logged meals have a slot"})
+		return false
+	}
+	return true
+}
+
+// computeIngredients Computes nutrients on the server for ingredients with a
+// food code, and the FNDDS release they came from. Client values that
+// disagree are returned as discrepancies, or rejected when the request sets
+// ?strict=true. Writes the error response and returns false on failure
+func (app *App) computeIngredients(c *gin.Context, ingredients []models.Ingredient) ([]models.Ingredient, string, []services.Discrepancy, bool) {
+	computed, version, discrepancies, err := services.ComputeIngredients(app.DB, app.FnddsRepo, ingredients)
+	var unknown *services.UnknownFoodCodesError
+	var unknownPortion *services.UnknownPortionError
+	var invalid *services.InvalidIngredientError
+	switch {
+	case errors.As(err, &unknown):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown food codes", "foodCodes": unknown.FoodCodes})
+		return nil, "", nil, false
+	case errors.As(err, &unknownPortion):
+		c.JSON(http.StatusBadRequest, gin.H{"error": unknownPortion.Error(), "portions": unknownPortion.Available})
+		return nil, "", nil, false
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalid.Error()})
+		return nil, "", nil, false
+	case err != nil:
+		log.Printf("❌ ComputeIngredients failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute nutrients"})
+		return nil, "", nil, false
+	}
+
+	if len(discrepancies) > 0 {
+		log.Printf("⚠️ %d client nutrient values disagree with FNDDS", len(discrepancies))
+		if c.Query("strict") == "true" {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":         "Nutrient values do not match FNDDS",
+				"discrepancies": discrepancies,
+			})
+			return nil, "", nil, false
+		}
+	}
+	return computed, version, discrepancies, true
+}
+
 // GET /dashboard/foodcode?name=Banana
 func (a *App) GetFoodCode(c *gin.Context) {
 	name := c.Query("name")
@@ -118,18 +209,126 @@ func (a *App) GetMealHistory(c *gin.Context) {
 		return
 	}
 
-	meals, err := repositories.GetMealsByUserID(a.DB, userID, "favorite")
+	a.listMeals(c, userID, "favorite")
+}
+
+// GET /dashboard/api/user-logged-meals
+func (a *App) GetLoggedMeals(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+	a.listMeals(c, userID, "history")
+}
+
+// mealPageSize is how many meals a page holds by default
+const mealPageSize = 50
+
+// maxMealPageSize caps the limit query parameter
+const maxMealPageSize = 200
+
+// listMeals Responds with a page of the user's meals of mealType, filtered by
+// the from, to, q (meal name), ingredient, foodCode and slot query parameters.
+// The cursor parameter continues from the nextCursor of a previous page
+func (a *App) listMeals(c *gin.Context, userID uuid.UUID, mealType string) {
+	filter, err := parseMealFilter(c, mealType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.mealPage(userID, filter)
 	if err != nil {
-		log.Println("❌ Failed to fetch meals from DB:", err)
+		log.Printf("❌ ListMeals failed: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meals"})
 		return
 	}
+	_ = a.audit(c, models.AuditMealsRead, userID, mealType)
+	c.JSON(http.StatusOK, page)
+}
+
+// mealPage Fetches the page of the user's meals the filter selects, with the
+// cursor of the next page when there is one
+func (a *App) mealPage(userID uuid.UUID, filter *models.MealFilter) (*models.MealPage, error) {
+	// One meal past the page tells whether another page follows
+	pageSize := filter.Limit
+	filter.Limit++
+	meals, total, err := repositories.ListMeals(a.DB, userID, filter)
+	if err != nil {
+		return nil, err
+	}
 
-	log.Printf("✅ Returning %d meals\n", len(meals))
-	c.JSON(http.StatusOK, meals)
+	page := &models.MealPage{Meals: meals, Total: total}
+	if len(meals) > pageSize {
+		page.Meals = meals[:pageSize]
+		last := page.Meals[pageSize-1]
+		page.NextCursor = encodeMealCursor(last.Time, last.ID)
+	}
+	return page, nil
 }
 
-func (a *App) GetLoggedMeals(c *gin.Context) {
+// parseMealFilter Reads a meal listing filter from the query
+func parseMealFilter(c *gin.Context, mealType string) (*models.MealFilter, error) {
+	filter := &models.MealFilter{
+		MealType:   mealType,
+		Slot:       c.Query("slot"),
+		Query:      strings.TrimSpace(c.Query("q")),
+		Ingredient: strings.TrimSpace(c.Query("ingredient")),
+	}
+	if !models.ValidMealSlot(filter.Slot) {
+		return nil, errors.New("invalid meal slot")
+	}
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		return nil, err
+	}
+	if v := c.Query("foodCode"); v != "" {
+		if filter.FoodCode, err = strconv.Atoi(v); err != nil || filter.FoodCode <= 0 {
+			return nil, errors.New("invalid food code")
+		}
+	}
+	if v := c.Query("cursor"); v != "" {
+		if filter.AfterTime, filter.AfterID, err = decodeMealCursor(v); err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+	}
+	if filter.Limit, err = parseLimit(c, mealPageSize, maxMealPageSize); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// encodeMealCursor Makes the opaque cursor of the page after a meal. Meals
+// are ordered by time then ID, so the cursor holds both
+func encodeMealCursor(t time.Time, id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixNano(), 10) + ":" + strconv.Itoa(id)))
+}
+
+// decodeMealCursor Reads the meal time and ID back from a cursor
+func decodeMealCursor(cursor string) (time.Time, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, 0, errors.New("malformed cursor")
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	mealID, err := strconv.Atoi(id)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return time.Unix(0, n).UTC(), mealID, nil
+}
+
+// PUT /dashboard/api/user-meal-history/:id
+func (app *App) UpdateMealEntry(c *gin.Context) {
 	claims := c.MustGet("claims").(*models.Claims)
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
@@ -137,42 +336,279 @@ func (a *App) GetLoggedMeals(c *gin.Context) {
 		return
 	}
 
-	meals, err := repositories.GetMealsByUserID(a.DB, userID, "history")
+	mealID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal ID"})
+		return
+	}
+
+	// Omitted fields keep their current value
+	var req struct {
+		MealName    *string              `json:"mealName"`
+		Time        *time.Time           `json:"time"`
+		MealType    *string              `json:"mealType"`
+		Slot        *string              `json:"slot"`
+		Ingredients *[]models.Ingredient `json:"ingredients"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal format"})
+		return
+	}
+
+	meal, err := repositories.GetMealByID(app.DB, userID, mealID)
+	if err != nil {
+		log.Printf("❌ GetMealByID failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+		return
+	}
+	if meal == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal not found"})
+		return
+	}
+
+	if req.MealName != nil {
+		meal.MealName = *req.MealName
+	}
+	if req.Time != nil {
+		meal.Time = *req.Time
+	}
+	if req.MealType != nil {
+		meal.MealType = *req.MealType
+		// A meal saved as a favorite is no longer a meal of the day
+		if meal.MealType == "favorite" && req.Slot == nil {
+			meal.Slot = ""
+		}
+	}
+	if req.Slot != nil {
+		meal.Slot = *req.Slot
+	}
+	var discrepancies []services.Discrepancy
+	if req.Ingredients != nil {
+		ingredients, version, found, ok := app.computeIngredients(c, *req.Ingredients)
+		if !ok {
+			return
+		}
+		meal.Ingredients, meal.FnddsVersion = ingredients, version
+		discrepancies = found
+	}
+
+	if meal.MealName == "" || len(meal.Ingredients) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Meal needs a name and at least one ingredient"})
+		return
+	}
+	if meal.MealType != "favorite" && meal.MealType != "history" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal type"})
+		return
+	}
+	if !validateMealSlot(c, meal.MealType, meal.Slot) {
+		return
+	}
+
+	updated, err := repositories.UpdateMeal(app.DB, userID, meal)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch logged meals"})
+		log.Printf("❌ UpdateMeal failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update meal"})
 		return
 	}
+	if !updated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal not found"})
+		return
+	}
+	_ = app.audit(c, models.AuditMealUpdate, userID, strconv.Itoa(meal.ID))
 
-	c.JSON(http.StatusOK, meals)
+	res := gin.H{
+		"message": "Meal updated",
+		"meal":    meal,
+		"totals":  models.SumIngredients(meal.Ingredients),
+	}
+	if len(discrepancies) > 0 {
+		res["discrepancies"] = discrepancies
+	}
+	c.JSON(http.StatusOK, res)
 }
 
-// DELETE /dashboard/user-meal-history
-func (app *App) DeleteMealEntry(c *gin.Context) {
+// POST /dashboard/api/favorites/:id/log
+//
+// Logs a favorite to history at time, now by default, as the meal slot.
+// servings multiplies every ingredient and grams sets the weight of
+// ingredients by index, e.g. {"servings": 1.5, "grams": {"2": 80}}. Nutrients
+// are recomputed here
+func (app *App) LogFavorite(c *gin.Context) {
 	claims := c.MustGet("claims").(*models.Claims)
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
 		return
 	}
+	enteredBy, err := uuid.Parse(claims.Actor())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	mealID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal ID"})
+		return
+	}
 
 	var req struct {
-		MealName string `json:"mealName"`
+		Time     *time.Time      `json:"time"`
+		Slot     string          `json:"slot"`
+		Servings *float64        `json:"servings"`
+		Grams    map[int]float64 `json:"grams"`
+	}
+	// The body is optional, a favorite is logged as is without one
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+	}
+	if !validateMealSlot(c, "history", req.Slot) {
+		return
+	}
+	servings := 1.0
+	if req.Servings != nil {
+		servings = *req.Servings
+	}
+	mealTime := time.Now()
+	if req.Time != nil {
+		mealTime = *req.Time
+	}
+
+	favorite, err := repositories.GetMealByID(app.DB, userID, mealID)
+	if err != nil {
+		log.Printf("❌ GetMealByID failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+		return
+	}
+	if favorite == nil || favorite.MealType != "favorite" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite not found"})
+		return
+	}
+
+	scaled, err := services.ScaleIngredients(favorite.Ingredients, servings, req.Grams)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	meal := &models.MealGroup{MealName: favorite.MealName, Time: mealTime, Slot: req.Slot}
+	app.logScaledMeal(c, userID, enteredBy, meal, scaled, "Favorite logged to history")
+}
+
+// logScaledMeal Logs a meal scaled from a favorite or recipe to history,
+// recomputing the nutrients of the scaled ingredients, and responds with the
+// meal, its totals and how it leaves the day's targets
+func (app *App) logScaledMeal(c *gin.Context, userID, enteredBy uuid.UUID, meal *models.MealGroup, scaled []models.Ingredient, message string) {
+	ingredients, version, _, ok := app.computeIngredients(c, scaled)
+	if !ok {
+		return
+	}
+
+	if err := repositories.InsertLoggedMeal(app.DB, userID, enteredBy, meal.MealName, meal.Time, meal.Slot, ingredients, version); err != nil {
+		log.Printf("❌ InsertLoggedMeal failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log meal"})
+		return
+	}
+	_ = app.audit(c, models.AuditMealCreate, userID, "history")
+
+	meal.MealType = "history"
+	meal.Ingredients = ingredients
+	meal.EnteredBy = &enteredBy
+	meal.FnddsVersion = version
+	totals := models.SumIngredients(ingredients)
+	res := gin.H{
+		"message": message,
+		"meal":    meal,
+		"totals":  totals,
 	}
+	usage, err := app.targetUsage(userID, meal.Time.Format("2006-01-02"), totals)
+	if err != nil {
+		log.Printf("⚠️ Failed to compare meal against targets: %v", err)
+	}
+	if usage != nil {
+		res["targets"] = usage
+	}
+	c.JSON(http.StatusCreated, res)
+}
 
-	if err := c.ShouldBindJSON(&req); err != nil || req.MealName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing meal name"})
+// DELETE /dashboard/api/meals/:id
+func (app *App) DeleteMealEntry(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
 		return
 	}
 
-	log.Printf("🧹 Deleting meal for user %s: mealName=%s\n", userID, req.MealName)
+	mealID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal ID"})
+		return
+	}
 
-	err = repositories.DeleteMealByName(app.DB, userID, req.MealName)
+	deleted, err := repositories.DeleteMealsByID(app.DB, userID, []int{mealID})
 	if err != nil {
+		log.Printf("❌ DeleteMealsByID failed: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete meal"})
 		return
 	}
+	if len(deleted) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal not found"})
+		return
+	}
+	_ = app.audit(c, models.AuditMealDelete, userID, strconv.Itoa(mealID))
 
-	c.JSON(http.StatusOK, gin.H{"message": "Meal deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "Meal deleted", "deleted": deleted[0]})
+}
+
+// DELETE /dashboard/api/meals
+func (app *App) DeleteMealEntries(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing meal IDs"})
+		return
+	}
+
+	deleted, err := repositories.DeleteMealsByID(app.DB, userID, req.IDs)
+	if err != nil {
+		log.Printf("❌ DeleteMealsByID failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete meals"})
+		return
+	}
+	if len(deleted) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No meals found"})
+		return
+	}
+
+	// Report requested IDs that were not removed
+	removed := map[int]bool{}
+	for _, m := range deleted {
+		removed[m.ID] = true
+		_ = app.audit(c, models.AuditMealDelete, userID, strconv.Itoa(m.ID))
+	}
+	notFound := []int{}
+	for _, id := range req.IDs {
+		if !removed[id] {
+			notFound = append(notFound, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Meals deleted",
+		"deleted":  deleted,
+		"notFound": notFound,
+	})
 }
 
 func (a *App) GetNutrientHistory(c *gin.Context) {
@@ -182,7 +618,15 @@ func (a *App) GetNutrientHistory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
+	_ = a.audit(c, models.AuditNutrientHistoryRead, userID, c.Query("start")+"/"+c.Query("end"))
+	a.respondNutrientHistory(c, userID)
+}
 
+// respondNutrientHistory Responds with the daily nutrient totals of a user
+// between the start and end query dates, flagging days over their targets.
+// slot narrows the totals to one meal slot and bySlot=true breaks each day
+// down by slot
+func (a *App) respondNutrientHistory(c *gin.Context, userID uuid.UUID) {
 	start := c.Query("start") + "T00:00:00"
 	end := c.Query("end") + "T23:59:59"
 
@@ -191,7 +635,13 @@ func (a *App) GetNutrientHistory(c *gin.Context) {
 		return
 	}
 
-	data, err := repositories.FetchNutrientHistory(a.DB, userID, start, end)
+	slot := c.Query("slot")
+	if !models.ValidMealSlot(slot) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal slot", "slots": models.MealSlots})
+		return
+	}
+
+	data, err := repositories.FetchNutrientHistory(a.DB, userID, start, end, slot, c.Query("bySlot") == "true")
 	if err != nil {
 		log.Printf("❌ Failed to fetch nutrient history: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch nutrient history"})
@@ -203,5 +653,16 @@ func (a *App) GetNutrientHistory(c *gin.Context) {
 		data = []repositories.DailyNutrientTotals{}
 	}
 
+	// Flag days over the user's limits, if any are set
+	targets, err := repositories.GetNutrientTargets(a.DB, userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to fetch nutrient targets: %v", err)
+	}
+	if targets != nil {
+		for i := range data {
+			data[i].Usage, data[i].OverLimit = targets.Usage(data[i].Totals())
+		}
+	}
+
 	c.JSON(http.StatusOK, data)
 }
@@ -7,13 +7,16 @@ import (
 	"github.com/google/uuid"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/kimsh02/kay-phos/server/gin/internal/handlers"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
 	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -25,6 +28,9 @@ func TestInsertMealHistory_Success(t *testing.T) {
 	mockDB := new(testutils.MockDB)
 	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).
 		Return(pgconn.CommandTag{}, nil)
+	// No targets set for this user
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).
+		Return(&testutils.MockRow{Err: pgx.ErrNoRows})
 
 	app := &handlers.App{DB: mockDB}
 
@@ -75,8 +81,10 @@ func TestGetMealHistory_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
 	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).
 		Return(new(testutils.MockRows), nil)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Values: []any{0}})
 
 	app := &handlers.App{DB: mockDB}
 
@@ -106,7 +114,10 @@ func TestDeleteMealEntry_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockDB := new(testutils.MockDB)
-	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, nil)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.ResultRows{
+		Rows: [][]any{{42, "TestMeal", time.Now(), "history"}},
+	}, nil)
 
 	app := &handlers.App{DB: mockDB}
 
@@ -123,20 +134,77 @@ func TestDeleteMealEntry_Success(t *testing.T) {
 		c.Next()
 	})
 
-	router.DELETE("/dashboard/api/user-meal-history", app.DeleteMealEntry)
+	router.DELETE("/dashboard/api/meals/:id", app.DeleteMealEntry)
 
-	payload := map[string]string{
-		"mealName": "TestMeal",
-		"mealTime": time.Now().Format(time.RFC3339),
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/dashboard/api/meals/42", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+
+	var res struct {
+		Deleted models.MealGroup `json:"deleted"`
 	}
-	jsonPayload, _ := json.Marshal(payload)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, 42, res.Deleted.ID)
+	assert.Equal(t, "TestMeal", res.Deleted.MealName)
+}
+
+func TestDeleteMealEntry_NotOwned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Meal does not exist or belongs to another user, nothing is returned
+	mockDB := new(testutils.MockDB)
+	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(new(testutils.MockRows), nil)
+
+	app := &handlers.App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.New().String()})
+		c.Next()
+	})
+	router.DELETE("/dashboard/api/meals/:id", app.DeleteMealEntry)
 
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("DELETE", "/dashboard/api/user-meal-history", bytes.NewBuffer(jsonPayload))
+	req, _ := http.NewRequest("DELETE", "/dashboard/api/meals/42", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestDeleteMealEntries_Bulk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.ResultRows{
+		Rows: [][]any{{1, "Breakfast", time.Now(), "history"}, {2, "Lunch", time.Now(), "history"}},
+	}, nil)
+
+	app := &handlers.App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.New().String()})
+		c.Next()
+	})
+	router.DELETE("/dashboard/api/meals", app.DeleteMealEntries)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/dashboard/api/meals", bytes.NewBuffer([]byte(`{"ids":[1,2,3]}`)))
 	req.Header.Set("Content-Type", "application/json")
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
+
+	var res struct {
+		Deleted  []models.MealGroup `json:"deleted"`
+		NotFound []int              `json:"notFound"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Deleted, 2)
+	assert.Equal(t, []int{3}, res.NotFound)
 }
 
 func TestInsertMealHistory_InvalidPayload(t *testing.T) {
@@ -189,12 +257,19 @@ func TestDeleteMealEntry_InvalidPayload(t *testing.T) {
 		c.Next()
 	})
 
-	router.DELETE("/dashboard/api/user-meal-history", app.DeleteMealEntry)
+	router.DELETE("/dashboard/api/meals/:id", app.DeleteMealEntry)
+	router.DELETE("/dashboard/api/meals", app.DeleteMealEntries)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/dashboard/api/meals/not-a-number", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
 
 	invalidPayload := []byte(`{"invalid": true}`)
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("DELETE", "/dashboard/api/user-meal-history", bytes.NewBuffer(invalidPayload))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/dashboard/api/meals", bytes.NewBuffer(invalidPayload))
 	req.Header.Set("Content-Type", "application/json")
 	router.ServeHTTP(w, req)
 
@@ -370,3 +445,405 @@ func TestGetMealHistory_QueryFails(t *testing.T) {
 
 	assert.Equal(t, 500, w.Code)
 }
+
+func setupUpdateMealRouter(mockDB *testutils.MockDB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	app := &handlers.App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.New().String()})
+		c.Next()
+	})
+	router.PUT("/dashboard/api/user-meal-history/:id", app.UpdateMealEntry)
+	return router
+}
+
+func TestUpdateMealEntry_Success(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mealTime := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{
+		Values: []any{7, "Breakfast", mealTime, "history", []models.Ingredient{{Name: "Banana", Grams: 1000, Potassium: 3580}}},
+	})
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+	router := setupUpdateMealRouter(mockDB)
+
+	// Fix a typo in grams, server recomputes totals
+	body := []byte(`{"ingredients":[{"name":"Banana","grams":100,"potassium":358,"phosphorus":22}]}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/dashboard/api/user-meal-history/7", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+
+	var res struct {
+		Meal   models.MealGroup   `json:"meal"`
+		Totals map[string]float64 `json:"totals"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, 7, res.Meal.ID)
+	assert.Equal(t, "Breakfast", res.Meal.MealName)
+	assert.True(t, mealTime.Equal(res.Meal.Time), "original timestamp should be kept")
+	assert.Equal(t, 358.0, res.Totals["potassium"])
+}
+
+func TestUpdateMealEntry_NotFound(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	router := setupUpdateMealRouter(mockDB)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/dashboard/api/user-meal-history/7", bytes.NewBuffer([]byte(`{"mealName":"Lunch"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestUpdateMealEntry_InvalidID(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupUpdateMealRouter(mockDB)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/dashboard/api/user-meal-history/abc", bytes.NewBuffer([]byte(`{"mealName":"Lunch"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
+
+func setupFoodCodeMealRouter(mockDB *testutils.MockDB) (*gin.Engine, *repositories.MockFnddsRepo) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(repositories.MockFnddsRepo)
+	mockRepo.On("FnddsLookup", mock.Anything, []int{1111}).Return(map[int]models.FnddsFoodItem{
+		1111: {FoodCode: 1111, Description: "Banana", Potassium: 358, Phosphorus: 22, Calories: 89, Protein: 1.1, Carbs: 23, DatasetVersion: "2021-2023"},
+	}, nil)
+	mockRepo.On("FnddsLookup", mock.Anything, []int{9999}).Return(map[int]models.FnddsFoodItem{}, nil)
+
+	app := &handlers.App{DB: mockDB, FnddsRepo: mockRepo}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.New().String()})
+		c.Next()
+	})
+	router.POST("/dashboard/api/user-meal-history", app.InsertMealHistory)
+	router.POST("/dashboard/api/favorites/:id/log", app.LogFavorite)
+	return router, mockRepo
+}
+
+func TestInsertMealHistory_ComputesFromFoodCode(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, nil)
+	router, _ := setupFoodCodeMealRouter(mockDB)
+
+	// Client claims far too little potassium for 200 g of banana
+	body := []byte(`{"mealName":"Snack","mealType":"favorite","time":"2025-03-01T10:00:00Z",
+		"ingredients":[{"name":"Banana","foodCode":1111,"grams":200,"potassium":50,"phosphorus":44}]}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/dashboard/api/user-meal-history", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 201, w.Code)
+
+	var res struct {
+		Discrepancies []struct {
+			Nutrient string  `json:"nutrient"`
+			Server   float64 `json:"server"`
+		} `json:"discrepancies"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Discrepancies, 1)
+	assert.Equal(t, "potassium", res.Discrepancies[0].Nutrient)
+	assert.Equal(t, 716.0, res.Discrepancies[0].Server)
+
+	// The stored ingredients carry the server computed values
+	args := mockDB.Calls[0].Arguments.Get(2).([]any)
+	stored := args[3].([]models.Ingredient)
+	assert.Equal(t, 716.0, stored[0].Potassium)
+	assert.True(t, stored[0].Verified)
+}
+
+func TestInsertMealHistory_ForgedVerified(t *testing.T) {
+	tests := []struct {
+		ingredients string
+		version     string
+	}{
+		// Nothing to look up
+		{`[{"name":"Cake","grams":100,"potassium":1,"verified":true,"fnddsVersion":"1999-2000"}]`, ""},
+		// Next to an ingredient that is computed, the meal gets the release
+		// it was looked up in
+		{`[{"name":"Cake","grams":100,"potassium":1,"verified":true,"fnddsVersion":"1999-2000"},{"name":"Banana","foodCode":1111,"grams":100}]`, "2021-2023"},
+	}
+	for _, tt := range tests {
+		mockDB := new(testutils.MockDB)
+		testutils.MockAuditLog(mockDB)
+		mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, nil)
+		router, _ := setupFoodCodeMealRouter(mockDB)
+
+		body := []byte(`{"mealName":"Snack","mealType":"favorite","time":"2025-03-01T10:00:00Z","ingredients":` + tt.ingredients + `}`)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/dashboard/api/user-meal-history", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, 201, w.Code, tt.ingredients)
+		args := mockDB.Calls[0].Arguments.Get(2).([]any)
+		stored := args[3].([]models.Ingredient)
+		assert.Equal(t, 1.0, stored[0].Potassium)
+		assert.False(t, stored[0].Verified)
+		assert.Empty(t, stored[0].FnddsVersion)
+		assert.Equal(t, tt.version, args[5])
+	}
+}
+
+func TestInsertMealHistory_StrictRejectsMismatch(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router, _ := setupFoodCodeMealRouter(mockDB)
+
+	body := []byte(`{"mealName":"Snack","mealType":"history","time":"2025-03-01T10:00:00Z",
+		"ingredients":[{"name":"Banana","foodCode":1111,"grams":100,"potassium":10}]}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/dashboard/api/user-meal-history?strict=true", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 422, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInsertMealHistory_UnknownFoodCode(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router, _ := setupFoodCodeMealRouter(mockDB)
+
+	body := []byte(`{"mealName":"Snack","mealType":"history","time":"2025-03-01T10:00:00Z",
+		"ingredients":[{"name":"Mystery","foodCode":9999,"grams":100}]}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/dashboard/api/user-meal-history", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInsertMealHistory_ComputeErrors(t *testing.T) {
+	tests := []struct {
+		ingredient string
+		code       int
+		error      string
+	}{
+		// A bad ingredient is the client's fault
+		{`{"name":"Banana","foodCode":1111,"grams":0}`, 400, `ingredient \"Banana\" needs a positive weight in grams`},
+		// A failed lookup is the server's, and its details stay in the log
+		{`{"name":"Rice","foodCode":5555,"grams":100}`, 500, "Failed to compute nutrients"},
+	}
+	for _, tt := range tests {
+		mockDB := new(testutils.MockDB)
+		router, mockRepo := setupFoodCodeMealRouter(mockDB)
+		mockRepo.On("FnddsLookup", mock.Anything, []int{5555}).Return(map[int]models.FnddsFoodItem(nil), errors.New("query error: connection reset"))
+
+		body := []byte(`{"mealName":"Snack","mealType":"history","time":"2025-03-01T10:00:00Z","ingredients":[` + tt.ingredient + `]}`)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/dashboard/api/user-meal-history", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.code, w.Code, tt.ingredient)
+		assert.JSONEq(t, `{"error":"`+tt.error+`"}`, w.Body.String())
+		mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestInsertMealHistory_Portion(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, nil)
+	router, mockRepo := setupFoodCodeMealRouter(mockDB)
+	mockRepo.On("FnddsLookup", mock.Anything, []int{1111, 1111}).Return(map[int]models.FnddsFoodItem{
+		1111: {FoodCode: 1111, Description: "Banana", Potassium: 358},
+	}, nil)
+	mockRepo.On("FnddsPortionsLookup", mock.Anything, []int{1111}).Return(map[int][]models.FnddsPortion{
+		1111: {{FoodCode: 1111, SeqNum: 1, Description: "1 cup, sliced", Grams: 150}, {FoodCode: 1111, SeqNum: 2, Description: "1 medium", Grams: 118}},
+	}, nil)
+
+	// Both portions of the banana come from one query
+	body := []byte(`{"mealName":"Snack","mealType":"favorite","time":"2025-03-01T10:00:00Z",
+		"ingredients":[{"name":"Banana","foodCode":1111,"portion":"1 MEDIUM","quantity":2},{"name":"Banana","foodCode":1111,"portion":"1 cup"}]}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/dashboard/api/user-meal-history", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 201, w.Code)
+
+	args := mockDB.Calls[0].Arguments.Get(2).([]any)
+	stored := args[3].([]models.Ingredient)
+	assert.Equal(t, 236.0, stored[0].Grams)
+	assert.Equal(t, "1 MEDIUM", stored[0].Portion)
+	assert.Equal(t, 150.0, stored[1].Grams)
+	mockRepo.AssertNumberOfCalls(t, "FnddsPortionsLookup", 1)
+
+	// Unknown portions are rejected with the available measures
+	body = []byte(`{"mealName":"Snack","mealType":"favorite","time":"2025-03-01T10:00:00Z",
+		"ingredients":[{"name":"Banana","foodCode":1111,"portion":"1 slice"}]}`)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/dashboard/api/user-meal-history", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestInsertMealHistory_ByCaregiver(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockDB := new(testutils.MockDB)
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+		Return(pgconn.CommandTag{}, nil)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).
+		Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	app := &handlers.App{DB: mockDB}
+
+	// Claims as PatientContextMiddleware leaves them
+	patientID, caregiverID := uuid.New(), uuid.New()
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: patientID.String(), ActorID: caregiverID.String()})
+		c.Next()
+	})
+	router.POST("/dashboard/api/user-meal-history", app.InsertMealHistory)
+
+	jsonPayload, _ := json.Marshal(models.MealGroup{
+		MealName:    "Lunch",
+		Time:        time.Now(),
+		MealType:    "favorite",
+		Ingredients: []models.Ingredient{{Name: "Rice", Grams: 100}},
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/dashboard/api/user-meal-history", bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	args := mockDB.Calls[0].Arguments.Get(2).([]any)
+	assert.Equal(t, patientID, args[0])
+	assert.Equal(t, caregiverID, args[6])
+}
+
+func setupLogFavoriteRouter(mockDB *testutils.MockDB, mealType string) *gin.Engine {
+	favorite := []models.Ingredient{
+		{Name: "Banana", FoodCode: 1111, Grams: 100, Potassium: 358, Phosphorus: 22, Verified: true},
+		{Name: "Homemade granola", Grams: 50, Potassium: 200, Phosphorus: 150, Calories: 240},
+	}
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.MatchedBy(func(sql string) bool {
+		return strings.Contains(sql, "WHERE id = $1 AND user_id = $2")
+	}), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{7, "Breakfast bowl", time.Now(), mealType, favorite, "2021-2023"},
+	})
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, nil)
+	router, _ := setupFoodCodeMealRouter(mockDB)
+	return router
+}
+
+func TestLogFavorite_Servings(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupLogFavoriteRouter(mockDB, "favorite")
+
+	body := []byte(`{"time":"2025-03-02T08:30:00Z","servings":1.5,"grams":{"1":25}}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/dashboard/api/favorites/7/log", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var res struct {
+		Meal   models.MealGroup   `json:"meal"`
+		Totals map[string]float64 `json:"totals"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "history", res.Meal.MealType)
+	// 150 g of banana from FNDDS plus the granola set to 25 g
+	assert.Equal(t, 537.0+100, res.Totals["potassium"])
+	assert.Equal(t, 33.0+75, res.Totals["phosphorus"])
+
+	mockDB.AssertCalled(t, "Exec", mock.Anything, mock.Anything, mock.MatchedBy(func(args []any) bool {
+		stored := args[3].([]models.Ingredient)
+		return args[1] == "Breakfast bowl" &&
+			args[2].(time.Time).Equal(time.Date(2025, 3, 2, 8, 30, 0, 0, time.UTC)) &&
+			stored[0].Grams == 150 && stored[0].Verified && stored[1].Grams == 25
+	}))
+}
+
+func TestLogFavorite_NotAFavorite(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupLogFavoriteRouter(mockDB, "history")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/dashboard/api/favorites/7/log", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLogFavorite_InvalidScaling(t *testing.T) {
+	for _, body := range []string{`{"servings":0}`, `{"grams":{"5":100}}`, `{"grams":{"0":-1}}`} {
+		mockDB := new(testutils.MockDB)
+		router := setupLogFavoriteRouter(mockDB, "favorite")
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/dashboard/api/favorites/7/log", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestInsertMealHistory_Slot(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, nil)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	router, _ := setupFoodCodeMealRouter(mockDB)
+
+	body := []byte(`{"mealName":"Pasta","mealType":"history","slot":"dinner","time":"2025-03-01T19:00:00Z",
+		"ingredients":[{"name":"Pasta","grams":200,"potassium":90}]}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/dashboard/api/user-meal-history", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockDB.AssertCalled(t, "Exec", mock.Anything, mock.Anything, mock.MatchedBy(func(args []any) bool {
+		return args[7] == models.MealSlotDinner
+	}))
+}
+
+func TestInsertMealHistory_InvalidSlot(t *testing.T) {
+	for _, meal := range []string{
+		`{"mealName":"Pasta","mealType":"history","slot":"brunch","ingredients":[{"name":"Pasta","grams":200}]}`,
+		`{"mealName":"Pasta","mealType":"favorite","slot":"dinner","ingredients":[{"name":"Pasta","grams":200}]}`,
+	} {
+		mockDB := new(testutils.MockDB)
+		router, _ := setupFoodCodeMealRouter(mockDB)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/dashboard/api/user-meal-history", bytes.NewBufferString(meal))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, meal)
+		mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
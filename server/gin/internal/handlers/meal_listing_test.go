@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- GetLoggedMeals(): filters, including the meal slot, reach the query, a
+//  page followed by more meals has a cursor that continues after its last
+//  meal, a last page that is exactly full has none, bad filters and cursors
+//  are rejected
+
+func setupMealListingRouter(mockDB *testutils.MockDB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.NewString()})
+		c.Next()
+	})
+	router.GET("/dashboard/api/user-logged-meals", app.GetLoggedMeals)
+	return router
+}
+
+func TestGetLoggedMeals_FiltersAndCursor(t *testing.T) {
+	newest := time.Date(2025, 3, 2, 18, 0, 0, 0, time.UTC)
+	oldest := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("Query", mock.Anything, sqlContaining("FROM meals"), mock.Anything).Return(&testutils.ResultRows{
+		Rows: [][]any{
+			{12, "Dinner", newest, nil, "2021-2023", nil},
+			{9, "Breakfast", oldest, nil, "2021-2023", nil},
+			{4, "Snack", oldest.Add(-time.Hour), nil, "2021-2023", nil},
+		},
+	}, nil)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("COUNT(*)"), mock.Anything).Return(&testutils.MockRow{Values: []any{5}})
+	router := setupMealListingRouter(mockDB)
+
+	req, _ := http.NewRequest("GET", "/dashboard/api/user-logged-meals?from=2025-03-01&to=2025-03-02&q=bowl&ingredient=rice&foodCode=56205000&slot=dinner&limit=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page models.MealPage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Meals, 2)
+	assert.Equal(t, 5, page.Total)
+	assert.Equal(t, encodeMealCursor(oldest, 9), page.NextCursor)
+
+	mockDB.AssertCalled(t, "Query", mock.Anything, sqlContaining("FROM meals"), mock.MatchedBy(func(args []any) bool {
+		from, to := args[2].(*time.Time), args[3].(*time.Time)
+		return args[1] == "history" &&
+			from.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) &&
+			to.Equal(time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)) &&
+			args[4] == "%bowl%" && args[5] == "%rice%" && args[6] == 56205000 && args[7] == "dinner" && args[10] == 3
+	}))
+
+	// The next page starts after the last meal of this one, and holds the
+	// last two meals so it has no cursor
+	next := new(testutils.MockDB)
+	testutils.MockAuditLog(next)
+	next.On("Query", mock.Anything, sqlContaining("FROM meals"), mock.Anything).Return(&testutils.ResultRows{
+		Rows: [][]any{
+			{4, "Snack", oldest.Add(-time.Hour), nil, "2021-2023", nil},
+			{2, "Lunch", oldest.Add(-20 * time.Hour), nil, "2021-2023", nil},
+		},
+	}, nil)
+	next.On("QueryRow", mock.Anything, sqlContaining("COUNT(*)"), mock.Anything).Return(&testutils.MockRow{Values: []any{5}})
+	router = setupMealListingRouter(next)
+
+	req, _ = http.NewRequest("GET", "/dashboard/api/user-logged-meals?limit=2&cursor="+page.NextCursor, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Meals, 2)
+	assert.NotContains(t, w.Body.String(), "nextCursor")
+	next.AssertCalled(t, "Query", mock.Anything, sqlContaining("FROM meals"), mock.MatchedBy(func(args []any) bool {
+		after := args[8].(*time.Time)
+		return after.Equal(oldest) && args[9] == 9
+	}))
+}
+
+func TestGetLoggedMeals_InvalidFilter(t *testing.T) {
+	for _, query := range []string{"from=yesterday", "foodCode=rice", "cursor=%21%21", "cursor=bm9wZQ", "limit=500", "slot=brunch"} {
+		mockDB := new(testutils.MockDB)
+		router := setupMealListingRouter(mockDB)
+
+		req, _ := http.NewRequest("GET", "/dashboard/api/user-logged-meals?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		mockDB.AssertNotCalled(t, "Query", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
@@ -10,6 +10,9 @@ func LoginPage(c *gin.Context) {
 func NewAccountPage(c *gin.Context) {
 	c.File("./public/html/new-account.html")
 }
+func ResetPasswordPage(c *gin.Context) {
+	c.File("./public/html/reset-password.html")
+}
 func DashboardPage(c *gin.Context) {
 	c.File("./public/html/dashboard.html")
 }
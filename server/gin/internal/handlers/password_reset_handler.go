@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+)
+
+/*
+ * handler for recovering a forgotten password
+ */
+
+// mailTimeout bounds how long a reset email may take to send
+const mailTimeout = 30 * time.Second
+
+// POST /forgot-password
+func (a *App) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPassword
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Every request counts, so the endpoint can't mail bomb an address
+	retryAfter, err := services.PasswordResetRetryAfter(a.DB, req.Email, c.ClientIP())
+	if err != nil {
+		log.Printf("❌ Checking password reset throttle failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password reset is unavailable, try again later."})
+		return
+	}
+	if retryAfter > 0 {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":      "Too many password reset requests. Try again later.",
+			"retryAfter": seconds,
+		})
+		return
+	}
+	if err := services.RecordPasswordResetRequest(a.DB, req.Email, c.ClientIP()); err != nil {
+		log.Printf("❌ Recording password reset request failed: %v", err)
+	}
+
+	// Sent in the background so the response takes as long for an unknown
+	// email as for a known one
+	baseURL := a.baseURL(c)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+		if err := services.RequestPasswordReset(ctx, a.DB, a.Mailer, req.Email, baseURL); err != nil {
+			log.Printf("❌ RequestPasswordReset failed: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account uses that email, a reset link is on its way."})
+}
+
+// POST /reset-password
+func (a *App) ResetPassword(c *gin.Context) {
+	var req models.PasswordReset
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := services.ResetPassword(a.DB, req.Token, req.NewPassword)
+	if errors.Is(err, services.ErrInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This reset link is invalid or has expired. Please request a new one."})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ ResetPassword failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	log.Printf("🔑 Password reset for %s", userID)
+	// The reset link proves who the actor is
+	_ = a.auditAs(c, userID, models.AuditPasswordReset, userID, "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset. Please login with your new password."})
+}
+
+// baseURL Returns where links in emails point to
+func (a *App) baseURL(c *gin.Context) string {
+	if a.BaseURL != "" {
+		return a.BaseURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	// The router only lets through known hosts
+	return scheme + "://" + c.Request.Host
+}
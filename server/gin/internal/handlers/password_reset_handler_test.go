@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kimsh02/kay-phos/server/gin/internal/mailer"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- ForgotPassword(): emails a link with the token to known addresses only,
+//  and answers the same either way, until the address or IP has asked too
+//  often
+//- ResetPassword(): sets the password and ends sessions for a valid token,
+//  rejects unknown or used tokens, and passwords too long to hash without
+//  using up the token
+
+func setupResetRouter(mockDB *testutils.MockDB, m mailer.Mailer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	app := &App{DB: mockDB, Mailer: m, BaseURL: "https://kayphos.com"}
+
+	router := gin.New()
+	router.POST("/forgot-password", app.ForgotPassword)
+	router.POST("/reset-password", app.ResetPassword)
+	return router
+}
+
+func TestForgotPassword_KnownEmail(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockLoginThrottle(mockDB)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("lower(email)"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{"Ada", "Lovelace", "ada", uuid.New(), "hash", "ada@example.com"},
+	})
+	mockDB.On("Exec", mock.Anything, sqlContaining("password_resets"), mock.Anything).Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+	m := &mailer.LogMailer{}
+	router := setupResetRouter(mockDB, m)
+
+	req, _ := http.NewRequest("POST", "/forgot-password", bytes.NewBufferString(`{"email": "Ada@Example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Eventually(t, func() bool { return len(m.Sent()) == 1 }, time.Second, 10*time.Millisecond)
+	msg := m.Sent()[0]
+	assert.Equal(t, "ada@example.com", msg.To)
+	assert.Contains(t, msg.Body, "https://kayphos.com/reset-password?token=")
+
+	// Only the hash of the emailed token is stored
+	token := strings.Fields(msg.Body[strings.Index(msg.Body, "token=")+len("token="):])[0]
+	mockDB.AssertCalled(t, "Exec", mock.Anything, sqlContaining("password_resets"), mock.MatchedBy(func(args []any) bool {
+		return args[1] != token
+	}))
+	// The request counted against the address
+	mockDB.AssertCalled(t, "QueryRow", mock.Anything, sqlContaining("RETURNING failures"), mock.MatchedBy(func(args []any) bool {
+		return args[0] == "reset:email:ada@example.com"
+	}))
+}
+
+func TestForgotPassword_UnknownEmail(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockLoginThrottle(mockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	m := &mailer.LogMailer{}
+	router := setupResetRouter(mockDB, m)
+
+	req, _ := http.NewRequest("POST", "/forgot-password", bytes.NewBufferString(`{"email": "nobody@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "If an account uses that email")
+	assert.Eventually(t, func() bool { return len(mockDB.Calls) == 4 }, time.Second, 10*time.Millisecond)
+	assert.Empty(t, m.Sent())
+}
+
+func TestForgotPassword_Throttled(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	until := time.Now().Add(90 * time.Second)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("MAX(locked_until)"), mock.Anything).Return(&testutils.MockRow{Values: []any{&until}})
+	m := &mailer.LogMailer{}
+	router := setupResetRouter(mockDB, m)
+
+	req, _ := http.NewRequest("POST", "/forgot-password", bytes.NewBufferString(`{"email": "ada@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	// Nothing is looked up or sent while locked
+	mockDB.AssertNumberOfCalls(t, "QueryRow", 1)
+	assert.Empty(t, m.Sent())
+}
+
+func TestForgotPassword_BadEmail(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupResetRouter(mockDB, &mailer.LogMailer{})
+
+	req, _ := http.NewRequest("POST", "/forgot-password", bytes.NewBufferString(`{"email": "not an email"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestResetPassword_Success(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("password_resets"), mock.Anything).Return(&testutils.MockRow{Values: []any{uuid.New()}})
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+	router := setupResetRouter(mockDB, &mailer.LogMailer{})
+
+	req, _ := http.NewRequest("POST", "/reset-password", bytes.NewBufferString(`{"token": "abc", "newPassword": "newpass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// Password update and revocation of every session
+	mockDB.AssertCalled(t, "Exec", mock.Anything, sqlContaining("hashed_password"), mock.Anything)
+	mockDB.AssertCalled(t, "Exec", mock.Anything, sqlContaining("user_sessions"), mock.Anything)
+}
+
+func TestResetPassword_TooLong(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupResetRouter(mockDB, &mailer.LogMailer{})
+
+	body := `{"token": "abc", "newPassword": "` + strings.Repeat("p", 73) + `"}`
+	req, _ := http.NewRequest("POST", "/reset-password", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "72 bytes")
+	mockDB.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResetPassword_InvalidToken(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	router := setupResetRouter(mockDB, &mailer.LogMailer{})
+
+	req, _ := http.NewRequest("POST", "/reset-password", bytes.NewBufferString(`{"token": "used", "newPassword": "newpass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+/*
+ * helpers shared by the clinician and caregiver handlers, which link other
+ * accounts to a patient
+ */
+
+// listPatientLinks Responds with the patient's links of kind under key
+func (a *App) listPatientLinks(c *gin.Context, kind, key string) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	links, err := repositories.ListPatientLinks(a.DB, userID, kind)
+	if err != nil {
+		log.Printf("❌ ListPatientLinks failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load " + key})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{key: links})
+}
+
+// inviteMember Invites the account named in the body to a link of kind with
+// the patient. When role is set only accounts with that role can be invited
+func (a *App) inviteMember(c *gin.Context, kind, role string) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	var req models.LinkInvite
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.User) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username or email of the " + kind + " is required"})
+		return
+	}
+	member := a.findUser(strings.TrimSpace(req.User))
+	if member == nil || (role != "" && member.Role != role) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No " + kind + " has that username or email"})
+		return
+	}
+	if member.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't invite yourself"})
+		return
+	}
+
+	link := &models.PatientLink{
+		ID:        uuid.New(),
+		PatientID: userID,
+		MemberID:  member.UserID,
+		Kind:      kind,
+		User: models.LinkedUser{
+			UserID:    member.UserID,
+			UserName:  member.UserName,
+			FirstName: member.FirstName,
+			LastName:  member.LastName,
+		},
+	}
+	err = repositories.CreatePatientLink(a.DB, link)
+	if errors.Is(err, repositories.ErrLinkExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This " + kind + " is already invited"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ CreatePatientLink failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite " + kind})
+		return
+	}
+	_ = a.audit(c, models.AuditLinkInvite, userID, link.ID.String())
+
+	c.JSON(http.StatusCreated, gin.H{kind: link})
+}
+
+// acceptLink Accepts the :id invitation of kind to the logged in user
+func (a *App) acceptLink(c *gin.Context, kind string) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+	linkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	patientID, err := repositories.AcceptPatientLink(a.DB, userID, linkID, kind)
+	if err != nil {
+		log.Printf("❌ AcceptPatientLink failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+	if patientID == uuid.Nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return
+	}
+	_ = a.audit(c, models.AuditLinkAccept, patientID, linkID.String())
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted"})
+}
+
+// listMemberLinks Responds with the logged in user's accepted links of kind,
+// or their pending invitations, under key
+func (a *App) listMemberLinks(c *gin.Context, kind, key string, accepted bool) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	links, err := repositories.ListMemberLinks(a.DB, userID, kind, accepted)
+	if err != nil {
+		log.Printf("❌ ListMemberLinks failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load " + key})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{key: links})
+}
+
+// revokeLink Ends the :id link of kind, which either side of it may do at
+// any time
+func (a *App) revokeLink(c *gin.Context, kind string) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+	linkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		return
+	}
+
+	link, err := repositories.RevokePatientLink(a.DB, userID, linkID, kind)
+	if err != nil {
+		log.Printf("❌ RevokePatientLink failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove access"})
+		return
+	}
+	if link == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+		return
+	}
+	_ = a.audit(c, models.AuditLinkRevoke, link.PatientID, linkID.String())
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access removed"})
+}
+
+// linkedPatient Returns the patient of the :id param if the logged in user
+// has an accepted link of kind to them. Responds 404 otherwise, so members
+// can't tell which patients exist
+func (a *App) linkedPatient(c *gin.Context, kind string) (uuid.UUID, bool) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return uuid.Nil, false
+	}
+	patientID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return uuid.Nil, false
+	}
+
+	linked, err := repositories.HasPatientLink(a.DB, patientID, userID, kind)
+	if err != nil {
+		log.Printf("❌ HasPatientLink failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check access to patient"})
+		return uuid.Nil, false
+	}
+	if !linked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		return uuid.Nil, false
+	}
+	return patientID, true
+}
+
+// findUser Looks up a user by username, or by email when login has an @.
+// Returns nil if there is none
+func (a *App) findUser(login string) *models.User {
+	if strings.Contains(login, "@") {
+		user, err := repositories.GetUserByEmail(a.DB, login)
+		if err != nil {
+			log.Printf("❌ GetUserByEmail failed: %v", err)
+		}
+		return user
+	}
+	user := &models.User{UserName: login}
+	if err := repositories.GetUser(a.DB, user); err != nil {
+		return nil
+	}
+	return user
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * helpers for query parameters shared by listing endpoints
+ */
+
+// parseTimeRange Reads the from and to query parameters, each an RFC 3339
+// time or a whole 2006-01-02 day. A whole day to is included, so the range
+// ends at the start of the next day. Missing ends are zero
+func parseTimeRange(c *gin.Context) (from, to time.Time, err error) {
+	if v := c.Query("from"); v != "" {
+		if from, _, err = parseQueryTime(v); err != nil {
+			return from, to, errors.New("invalid from date")
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		var day bool
+		if to, day, err = parseQueryTime(v); err != nil {
+			return from, to, errors.New("invalid to date")
+		}
+		if day {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	return from, to, nil
+}
+
+// parseQueryTime Parses an RFC 3339 time or a 2006-01-02 day, reporting
+// which it was
+func parseQueryTime(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	return t, true, err
+}
+
+// parseLimit Reads the limit query parameter, or returns def without one
+func parseLimit(c *gin.Context, def, max int) (int, error) {
+	v := c.Query("limit")
+	if v == "" {
+		return def, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit <= 0 || limit > max {
+		return 0, fmt.Errorf("limit must be between 1 and %d", max)
+	}
+	return limit, nil
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+)
+
+/*
+ * handler for recipes, dishes cooked once and logged a serving at a time.
+ * Ingredients are resolved and computed the same way as /calculate-intake
+ */
+
+// GET /dashboard/api/recipes
+func (a *App) ListRecipes(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	recipes, err := repositories.ListRecipes(a.DB, userID)
+	if err != nil {
+		log.Printf("❌ ListRecipes failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recipes": recipes})
+}
+
+// GET /dashboard/api/recipes/:id
+func (a *App) GetRecipe(c *gin.Context) {
+	_, recipe, ok := a.ownRecipe(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, recipe)
+}
+
+// POST /dashboard/api/recipes
+//
+// Ingredients need a food code, or a name to search FNDDS for, and grams or
+// a portion and quantity. Nutrients are always computed here
+func (a *App) CreateRecipe(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	recipe, ok := a.bindRecipe(c)
+	if !ok {
+		return
+	}
+	if err := repositories.InsertRecipe(a.DB, userID, recipe); err != nil {
+		log.Printf("❌ InsertRecipe failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recipe"})
+		return
+	}
+	_ = a.audit(c, models.AuditRecipeCreate, userID, strconv.Itoa(recipe.ID))
+
+	c.JSON(http.StatusCreated, recipe)
+}
+
+// PUT /dashboard/api/recipes/:id
+func (a *App) UpdateRecipe(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+	recipeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID"})
+		return
+	}
+
+	recipe, ok := a.bindRecipe(c)
+	if !ok {
+		return
+	}
+	recipe.ID = recipeID
+	updated, err := repositories.UpdateRecipe(a.DB, userID, recipe)
+	if err != nil {
+		log.Printf("❌ UpdateRecipe failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recipe"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	_ = a.audit(c, models.AuditRecipeUpdate, userID, strconv.Itoa(recipe.ID))
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// DELETE /dashboard/api/recipes/:id
+func (a *App) DeleteRecipe(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+	recipeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID"})
+		return
+	}
+
+	deleted, err := repositories.DeleteRecipe(a.DB, userID, recipeID)
+	if err != nil {
+		log.Printf("❌ DeleteRecipe failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recipe"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+	_ = a.audit(c, models.AuditRecipeDelete, userID, strconv.Itoa(recipeID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recipe deleted"})
+}
+
+// POST /dashboard/api/recipes/:id/log
+//
+// Logs what was eaten of a recipe to history, {"servings": 2} or
+// {"grams": 350} of the cooked dish, at time (now by default) as the meal slot
+func (a *App) LogRecipe(c *gin.Context) {
+	userID, recipe, ok := a.ownRecipe(c)
+	if !ok {
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+	enteredBy, err := uuid.Parse(claims.Actor())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	var req struct {
+		Servings float64    `json:"servings"`
+		Grams    float64    `json:"grams"`
+		Time     *time.Time `json:"time"`
+		Slot     string     `json:"slot"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if !validateMealSlot(c, "history", req.Slot) {
+		return
+	}
+	share, err := recipe.Share(req.Servings, req.Grams)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	scaled, err := services.ScaleIngredients(recipe.Ingredients, share, nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meal := &models.MealGroup{MealName: recipe.Name, Time: time.Now(), Slot: req.Slot}
+	if req.Time != nil {
+		meal.Time = *req.Time
+	}
+	a.logScaledMeal(c, userID, enteredBy, meal, scaled, "Recipe logged to history")
+}
+
+// ownRecipe Fetches the recipe of the :id param owned by the user. Writes
+// the error response and returns false when there is none
+func (a *App) ownRecipe(c *gin.Context) (uuid.UUID, *models.Recipe, bool) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return uuid.Nil, nil, false
+	}
+	recipeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID"})
+		return uuid.Nil, nil, false
+	}
+
+	recipe, err := repositories.GetRecipe(a.DB, userID, recipeID)
+	if err != nil {
+		log.Printf("❌ GetRecipe failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipe"})
+		return uuid.Nil, nil, false
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return uuid.Nil, nil, false
+	}
+	return userID, recipe, true
+}
+
+// bindRecipe Reads a recipe from the body and resolves its ingredients in
+// FNDDS. Writes the error response and returns false on failure
+func (a *App) bindRecipe(c *gin.Context) (*models.Recipe, bool) {
+	var recipe models.Recipe
+	if err := c.ShouldBindJSON(&recipe); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe format"})
+		return nil, false
+	}
+	if err := recipe.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	var notFound []string
+	var unknownCodes []int
+	for i, food := range recipe.Ingredients {
+		ing, err := services.ResolveFood(a.DB, a.FnddsRepo, food)
+		var unknown *services.UnknownFoodCodesError
+		var unknownPortion *services.UnknownPortionError
+		var invalid *services.InvalidIngredientError
+		switch {
+		case errors.Is(err, services.ErrFoodNotFound):
+			notFound = append(notFound, food.Name)
+		case errors.As(err, &unknown):
+			unknownCodes = append(unknownCodes, unknown.FoodCodes...)
+		case errors.As(err, &unknownPortion):
+			c.JSON(http.StatusBadRequest, gin.H{"error": unknownPortion.Error(), "portions": unknownPortion.Available})
+			return nil, false
+		case errors.As(err, &invalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalid.Error()})
+			return nil, false
+		case err != nil:
+			log.Printf("❌ ResolveFood failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up ingredients"})
+			return nil, false
+		}
+		recipe.Ingredients[i] = ing
+	}
+	if len(notFound) > 0 || len(unknownCodes) > 0 {
+		res := gin.H{"error": "Some ingredients were not found in FNDDS"}
+		if len(notFound) > 0 {
+			res["ingredients"] = notFound
+		}
+		if len(unknownCodes) > 0 {
+			res["foodCodes"] = unknownCodes
+		}
+		c.JSON(http.StatusBadRequest, res)
+		return nil, false
+	}
+	return &recipe, true
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- CreateRecipe(): ingredients are resolved by food code or by searching
+//  their name, totals and per-serving nutrients are computed, ingredients
+//  missing from FNDDS are listed back, lookup failures are server errors
+//- LogRecipe(): servings or grams eaten log the matching share of every
+//  ingredient to history, grams need a cooked weight, unknown recipes 404
+
+func setupRecipeRouter(mockDB *testutils.MockDB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	banana := models.FnddsFoodItem{FoodCode: 1111, Description: "Banana", Potassium: 358, Phosphorus: 22, Calories: 89}
+	rice := models.FnddsFoodItem{FoodCode: 2222, Description: "Rice, white, cooked", Potassium: 35, Phosphorus: 43, Calories: 130}
+	mockRepo := new(repositories.MockFnddsRepo)
+	mockRepo.On("FnddsQuery", mock.Anything, "flour").Return((*[]models.FnddsFoodItem)(nil), errors.New("query error: connection reset"))
+	mockRepo.On("FnddsQuery", mock.Anything, "rice").Return(&[]models.FnddsFoodItem{rice}, nil)
+	mockRepo.On("FnddsQuery", mock.Anything, mock.Anything).Return(&[]models.FnddsFoodItem{}, nil)
+	mockRepo.On("FnddsLookup", mock.Anything, []int{9999}).Return(map[int]models.FnddsFoodItem{}, nil)
+	mockRepo.On("FnddsLookup", mock.Anything, mock.Anything).Return(map[int]models.FnddsFoodItem{1111: banana, 2222: rice}, nil)
+
+	app := &App{DB: mockDB, FnddsRepo: mockRepo}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.NewString()})
+		c.Next()
+	})
+	router.POST("/dashboard/api/recipes", app.CreateRecipe)
+	router.POST("/dashboard/api/recipes/:id/log", app.LogRecipe)
+	return router
+}
+
+// mockStoredRecipe Mocks the recipe fetched by id, 200 g of banana and 400 g
+// of rice making 4 servings
+func mockStoredRecipe(mockDB *testutils.MockDB, cookedGrams float64) {
+	ingredients := []models.Ingredient{
+		{Name: "Banana", FoodCode: 1111, Grams: 200, Potassium: 716, Phosphorus: 44, Verified: true},
+		{Name: "rice", FoodCode: 2222, Grams: 400, Potassium: 140, Phosphorus: 172, Verified: true},
+	}
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM recipes"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{3, "Banana rice", 4.0, cookedGrams, ingredients, time.Now(), time.Now()},
+	})
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, nil)
+}
+
+func postJSON(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateRecipe_ResolvesIngredients(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("INSERT INTO recipes"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{3, time.Now(), time.Now()},
+	})
+	router := setupRecipeRouter(mockDB)
+
+	// "rice pilaf" finds nothing and is searched again as "rice"
+	w := postJSON(router, "/dashboard/api/recipes", `{"name":"Banana rice","servings":4,"cookedGrams":800,
+		"ingredients":[{"name":"Banana","foodCode":1111,"grams":200},{"name":"rice pilaf","grams":400}]}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var recipe models.Recipe
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &recipe))
+	assert.Equal(t, 3, recipe.ID)
+	assert.Equal(t, 716.0+140, recipe.Totals["potassium"])
+	assert.Equal(t, (716.0+140)/4, recipe.PerServing["potassium"])
+	assert.Equal(t, (44.0+172)/4, recipe.PerServing["phosphorus"])
+	assert.Equal(t, "rice pilaf", recipe.Ingredients[1].Name)
+	assert.Equal(t, 2222, recipe.Ingredients[1].FoodCode)
+}
+
+func TestCreateRecipe_IngredientsNotFound(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupRecipeRouter(mockDB)
+
+	w := postJSON(router, "/dashboard/api/recipes", `{"name":"Fruit salad","servings":2,
+		"ingredients":[{"name":"dragonfruit","grams":100},{"name":"Mystery","foodCode":9999,"grams":50}]}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"Some ingredients were not found in FNDDS","ingredients":["dragonfruit"],"foodCodes":[9999]}`, w.Body.String())
+	mockDB.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateRecipe_ResolveErrors(t *testing.T) {
+	tests := []struct {
+		ingredient string
+		code       int
+		error      string
+	}{
+		{`{"name":"rice","grams":0}`, http.StatusBadRequest, `ingredient \"rice\" needs a positive weight in grams`},
+		{`{"grams":100}`, http.StatusBadRequest, "ingredient needs a name or a food code"},
+		{`{"name":"flour","grams":100}`, http.StatusInternalServerError, "Failed to look up ingredients"},
+	}
+	for _, tt := range tests {
+		mockDB := new(testutils.MockDB)
+		router := setupRecipeRouter(mockDB)
+
+		w := postJSON(router, "/dashboard/api/recipes", `{"name":"Bread","servings":8,"ingredients":[`+tt.ingredient+`]}`)
+
+		assert.Equal(t, tt.code, w.Code, tt.ingredient)
+		assert.JSONEq(t, `{"error":"`+tt.error+`"}`, w.Body.String())
+		mockDB.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestLogRecipe_ServingsAndGrams(t *testing.T) {
+	tests := []struct {
+		body      string
+		potassium float64
+		banana    float64
+	}{
+		{`{"servings":2,"time":"2025-03-02T18:00:00Z","slot":"dinner"}`, 358 + 70, 100},
+		{`{"grams":200,"time":"2025-03-02T18:00:00Z","slot":"dinner"}`, 179 + 35, 50},
+	}
+	for _, tt := range tests {
+		mockDB := new(testutils.MockDB)
+		mockStoredRecipe(mockDB, 800)
+		router := setupRecipeRouter(mockDB)
+
+		w := postJSON(router, "/dashboard/api/recipes/3/log", tt.body)
+
+		assert.Equal(t, http.StatusCreated, w.Code, tt.body)
+		var res struct {
+			Meal   models.MealGroup   `json:"meal"`
+			Totals map[string]float64 `json:"totals"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, "history", res.Meal.MealType)
+		assert.Equal(t, "dinner", res.Meal.Slot)
+		assert.InDelta(t, tt.potassium, res.Totals["potassium"], 1e-9)
+
+		mockDB.AssertCalled(t, "Exec", mock.Anything, mock.Anything, mock.MatchedBy(func(args []any) bool {
+			stored := args[3].([]models.Ingredient)
+			return args[1] == "Banana rice" &&
+				args[2].(time.Time).Equal(time.Date(2025, 3, 2, 18, 0, 0, 0, time.UTC)) &&
+				stored[0].Grams == tt.banana
+		}))
+	}
+}
+
+func TestLogRecipe_InvalidShare(t *testing.T) {
+	for _, body := range []string{`{}`, `{"servings":1,"grams":100}`, `{"servings":-1}`, `{"grams":100}`} {
+		mockDB := new(testutils.MockDB)
+		mockStoredRecipe(mockDB, 0)
+		router := setupRecipeRouter(mockDB)
+
+		w := postJSON(router, "/dashboard/api/recipes/3/log", body)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestLogRecipe_NotFound(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	router := setupRecipeRouter(mockDB)
+
+	w := postJSON(router, "/dashboard/api/recipes/3/log", `{"servings":1}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
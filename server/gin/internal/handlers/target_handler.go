@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+/*
+ * handler for daily nutrient targets
+ */
+
+// GET /dashboard/api/targets
+func (a *App) GetTargets(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	targets, err := repositories.GetNutrientTargets(a.DB, userID)
+	if err != nil {
+		log.Printf("❌ GetNutrientTargets failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch targets"})
+		return
+	}
+	if targets == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No targets set"})
+		return
+	}
+
+	c.JSON(http.StatusOK, targets)
+}
+
+// PUT /dashboard/api/targets
+func (a *App) UpdateTargets(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	var targets models.NutrientTargets
+	if err := c.ShouldBindJSON(&targets); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid targets format"})
+		return
+	}
+	if err := targets.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.UpsertNutrientTargets(a.DB, userID, &targets); err != nil {
+		log.Printf("❌ UpsertNutrientTargets failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save targets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Targets saved"})
+}
+
+// DELETE /dashboard/api/targets
+func (a *App) DeleteTargets(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": s})
+		return
+	}
+
+	deleted, err := repositories.DeleteNutrientTargets(a.DB, userID)
+	if err != nil {
+		log.Printf("❌ DeleteNutrientTargets failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete targets"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No targets set"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Targets deleted"})
+}
+
+// targetUsage compares a meal against the user's daily targets. It returns
+// nil if the user has no targets. The day's totals already include the meal,
+// so subtracting it tells whether this meal is the one that crossed a limit
+func (a *App) targetUsage(userID uuid.UUID, day string, mealTotals map[string]float64) (gin.H, error) {
+	targets, err := repositories.GetNutrientTargets(a.DB, userID)
+	if err != nil || targets == nil {
+		return nil, err
+	}
+
+	days, err := repositories.FetchNutrientHistory(a.DB, userID, day+"T00:00:00", day+"T23:59:59", "", false)
+	if err != nil {
+		return nil, err
+	}
+	dayTotals := map[string]float64{}
+	if len(days) > 0 {
+		dayTotals = days[0].Totals()
+	}
+
+	dailyUsage, overLimit := targets.Usage(dayTotals)
+	mealUsage, _ := targets.Usage(mealTotals)
+	pushedOver := []string{}
+	for nutrient, u := range dailyUsage {
+		if u.OverLimit && u.Consumed-mealTotals[nutrient] <= u.Limit {
+			pushedOver = append(pushedOver, nutrient)
+		}
+	}
+
+	return gin.H{
+		"mealUsage":  mealUsage,
+		"dailyUsage": dailyUsage,
+		"overLimit":  overLimit,
+		"pushedOver": pushedOver,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kimsh02/kay-phos/server/gin/internal/handlers"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTargetRouter(mockDB *testutils.MockDB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	app := &handlers.App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.New().String()})
+		c.Next()
+	})
+	router.GET("/dashboard/api/targets", app.GetTargets)
+	router.PUT("/dashboard/api/targets", app.UpdateTargets)
+	router.DELETE("/dashboard/api/targets", app.DeleteTargets)
+	return router
+}
+
+func TestGetTargets_NotSet(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	router := setupTargetRouter(mockDB)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/dashboard/api/targets", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestUpdateTargets_Success(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, nil)
+	router := setupTargetRouter(mockDB)
+
+	body := []byte(`{"ckdStage":3,"potassium":2000,"phosphorus":800,"protein":50,"calories":2000}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/dashboard/api/targets", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	mockDB.AssertCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateTargets_NegativeLimit(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router := setupTargetRouter(mockDB)
+
+	body := []byte(`{"potassium":-1}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/dashboard/api/targets", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteTargets_NotSet(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("DELETE 0"), nil)
+	router := setupTargetRouter(mockDB)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/dashboard/api/targets", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+)
+
+/*
+ * handler for TOTP two-factor authentication settings
+ */
+
+// GET /dashboard/api/totp
+func (a *App) GetTOTPStatus(c *gin.Context) {
+	user, _, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	totp, err := repositories.GetTOTP(a.DB, user.UserID)
+	if err != nil {
+		log.Printf("❌ GetTOTP failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": totp != nil && totp.Enabled})
+}
+
+// POST /dashboard/api/totp/enroll
+func (a *App) EnrollTOTP(c *gin.Context) {
+	user, _, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	secret, err := services.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+	saved, err := repositories.SavePendingTOTP(a.DB, user.UserID, secret)
+	if err != nil {
+		log.Printf("❌ SavePendingTOTP failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+	if !saved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	// The secret is shown as text and as a QR code of the URI
+	c.JSON(http.StatusOK, gin.H{
+		"secret":     secret,
+		"otpauthUri": services.TOTPURI(secret, user.UserName),
+	})
+}
+
+// POST /dashboard/api/totp/verify
+func (a *App) VerifyTOTP(c *gin.Context) {
+	var req models.TOTPCode
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code is required"})
+		return
+	}
+	user, _, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	totp, err := repositories.GetTOTP(a.DB, user.UserID)
+	if err != nil {
+		log.Printf("❌ GetTOTP failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if totp == nil || totp.Enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "No enrollment is pending"})
+		return
+	}
+	step, ok := services.MatchTOTP(totp.Secret, req.Code, time.Now(), 0)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid authentication code"})
+		return
+	}
+
+	codes, hashes, err := services.GenerateRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+	enabled, err := repositories.EnableTOTP(a.DB, user.UserID, step, hashes)
+	if err != nil {
+		log.Printf("❌ EnableTOTP failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	if !enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "No enrollment is pending"})
+		return
+	}
+	log.Printf("🔐 Enabled TOTP for %s", user.UserID)
+	_ = a.audit(c, models.AuditTOTPEnable, user.UserID, "")
+
+	// Recovery codes are only ever shown here
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// DELETE /dashboard/api/totp
+func (a *App) DisableTOTP(c *gin.Context) {
+	var req models.TOTPCode
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password is required to disable two-factor authentication"})
+		return
+	}
+	user, _, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	if err := user.CheckPassword(req.Password); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	totp, err := repositories.GetTOTP(a.DB, user.UserID)
+	if err != nil {
+		log.Printf("❌ GetTOTP failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	// A pending enrollment can be dropped without a code
+	if totp != nil && totp.Enabled {
+		err = services.VerifySecondFactor(a.DB, user.UserID, totp, &req)
+		if errors.Is(err, services.ErrInvalidSecondFactor) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid authentication code"})
+			return
+		}
+		if err != nil {
+			log.Printf("❌ VerifySecondFactor failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+			return
+		}
+	}
+
+	if _, err := repositories.DeleteTOTP(a.DB, user.UserID); err != nil {
+		log.Printf("❌ DeleteTOTP failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	log.Printf("🔓 Disabled TOTP for %s", user.UserID)
+	_ = a.audit(c, models.AuditTOTPDisable, user.UserID, "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- EnrollTOTP(): returns the secret and otpauth URI, 409 once enabled
+//- VerifyTOTP(): enables TOTP on a valid first code and returns recovery codes
+//- DisableTOTP(): requires the password and a second factor
+
+const totpTestSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func setupTOTPRouter(mockDB *testutils.MockDB, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: userID.String(), SessionID: uuid.New().String()})
+		c.Next()
+	})
+	router.GET("/api/totp", app.GetTOTPStatus)
+	router.POST("/api/totp/enroll", app.EnrollTOTP)
+	router.POST("/api/totp/verify", app.VerifyTOTP)
+	router.DELETE("/api/totp", app.DisableTOTP)
+	return router
+}
+
+func TestEnrollTOTP_Success(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockUserRow(userID, "pass"))
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+	router := setupTOTPRouter(mockDB, userID)
+
+	req, _ := http.NewRequest("POST", "/api/totp/enroll", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp["secret"], 32)
+	assert.Contains(t, resp["otpauthUri"], "otpauth://totp/KayPhos:ada?")
+	assert.Contains(t, resp["otpauthUri"], "secret="+resp["secret"])
+}
+
+func TestEnrollTOTP_AlreadyEnabled(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockUserRow(userID, "pass"))
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("INSERT 0 0"), nil)
+	router := setupTOTPRouter(mockDB, userID)
+
+	req, _ := http.NewRequest("POST", "/api/totp/enroll", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestVerifyTOTP_Success(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_userid_query", mock.Anything).Return(mockUserRow(userID, "pass"))
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM user_totp"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{totpTestSecret, false, int64(0)},
+	})
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("totp_recovery_codes"), mock.Anything).Return(&testutils.MockRow{Values: []any{1}})
+	router := setupTOTPRouter(mockDB, userID)
+
+	code, _ := services.TOTPCode(totpTestSecret, services.TOTPStep(time.Now()))
+	req, _ := http.NewRequest("POST", "/api/totp/verify", bytes.NewBufferString(`{"code": "`+code+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string][]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp["recoveryCodes"], services.RecoveryCodeCount)
+}
+
+func TestVerifyTOTP_WrongCode(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_userid_query", mock.Anything).Return(mockUserRow(userID, "pass"))
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM user_totp"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{totpTestSecret, false, int64(0)},
+	})
+	router := setupTOTPRouter(mockDB, userID)
+
+	req, _ := http.NewRequest("POST", "/api/totp/verify", bytes.NewBufferString(`{"code": "000000x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	// Nothing enabled
+	mockDB.AssertNumberOfCalls(t, "QueryRow", 2)
+}
+
+func TestVerifyTOTP_NothingPending(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_userid_query", mock.Anything).Return(mockUserRow(userID, "pass"))
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM user_totp"), mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	router := setupTOTPRouter(mockDB, userID)
+
+	req, _ := http.NewRequest("POST", "/api/totp/verify", bytes.NewBufferString(`{"code": "123456"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestDisableTOTP_Success(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_userid_query", mock.Anything).Return(mockUserRow(userID, "pass"))
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM user_totp"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{totpTestSecret, true, int64(0)},
+	})
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+	router := setupTOTPRouter(mockDB, userID)
+
+	code, _ := services.TOTPCode(totpTestSecret, services.TOTPStep(time.Now()))
+	body := `{"password": "pass", "code": "` + code + `"}`
+	req, _ := http.NewRequest("DELETE", "/api/totp", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// Code step used up, then the TOTP row deleted
+	mockDB.AssertNumberOfCalls(t, "Exec", 2)
+}
+
+func TestDisableTOTP_MissingCode(t *testing.T) {
+	userID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_userid_query", mock.Anything).Return(mockUserRow(userID, "pass"))
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM user_totp"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{totpTestSecret, true, int64(0)},
+	})
+	router := setupTOTPRouter(mockDB, userID)
+
+	req, _ := http.NewRequest("DELETE", "/api/totp", bytes.NewBufferString(`{"password": "pass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockDB.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/google/uuid"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kimsh02/kay-phos/server/gin/internal/middleware"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
 	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
 	"github.com/kimsh02/kay-phos/server/gin/internal/services"
@@ -37,32 +42,229 @@ func MakeUserHandler(fn func(*gin.Context, *models.User)) gin.HandlerFunc {
 	}
 }
 
+// loginFailed is the only error a failed login gets, so it does not reveal
+// whether the username exists
+const loginFailed = "Invalid username or password."
+
 // LoginUser verify User logging in
 func (a *App) LoginUser(c *gin.Context, user *models.User) {
-	// Get user from db
-	if err := repositories.GetUser(a.DB, user); err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !a.checkLogin(c, user) {
 		return
 	}
-	// Verify input password
-	if !user.VerifyPassword() {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Password is invalid."})
+	// Users with two-factor authentication finish at /login/totp
+	mfaToken, ok := a.mfaChallenge(c, user)
+	if !ok {
+		return
+	}
+	if mfaToken != "" {
+		c.SetCookie("mfa_token", mfaToken, int(services.MFATokenTTL.Seconds()), "/", "", false, true)
+		c.IndentedJSON(http.StatusOK, gin.H{"mfaRequired": true})
+		return
+	}
+
+	tokens, ok := a.startSession(c, user)
+	if !ok {
 		return
 	}
-	// Generate JWT for user
-	token, err := services.GenerateToken(user)
+	// Set tokens as secure cookies and return success
+	middleware.SetSessionCookies(c, tokens)
+
+	// c.Redirect(http.StatusSeeOther, "/dashboard")
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Login successful."})
+}
+
+// checkLogin Runs the lockout and password checks of a login, responding
+// itself when one fails
+func (a *App) checkLogin(c *gin.Context, user *models.User) bool {
+	// Refuse locked out usernames and IPs before checking anything
+	if a.loginLockedOut(c, user.UserName) {
+		return false
+	}
+
+	// Get user from db by the username the lockout was checked for, never a
+	// client sent userid, and verify input password. An unknown username
+	// still pays for a bcrypt compare
+	user.UserID = uuid.Nil
+	user.HashedPassword = ""
+	subjectID := uuid.Nil
+	if err := repositories.GetUser(a.DB, user); err != nil {
+		user.HashedPassword = ""
+	} else {
+		subjectID = user.UserID
+	}
+	if !user.VerifyPassword() {
+		if err := services.RecordLoginFailure(a.DB, user.UserName, c.ClientIP()); err != nil {
+			log.Println("❌ Recording failed login failed:", err)
+		}
+		// Nobody is logged in to be the actor, and unknown usernames have no
+		// subject
+		_ = a.auditAs(c, uuid.Nil, models.AuditLoginFailed, subjectID, "password")
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": loginFailed})
+		return false
+	}
+	return true
+}
+
+// loginLockedOut Responds 429 when the username or client IP has failed too
+// often, or 500 when that can't be checked
+func (a *App) loginLockedOut(c *gin.Context, userName string) bool {
+	retryAfter, err := services.LoginRetryAfter(a.DB, userName, c.ClientIP())
+	if err != nil {
+		log.Println("❌ Checking login lockout failed:", err)
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "Login is unavailable, try again later."})
+		return true
+	}
+	if retryAfter > 0 {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.IndentedJSON(http.StatusTooManyRequests, gin.H{
+			"error":      "Too many failed login attempts. Try again later.",
+			"retryAfter": seconds,
+		})
+		return true
+	}
+	return false
+}
+
+// mfaChallenge Returns a token for the second login step when the user has
+// two-factor authentication, or "" when the password is enough
+func (a *App) mfaChallenge(c *gin.Context, user *models.User) (string, bool) {
+	totp, err := repositories.GetTOTP(a.DB, user.UserID)
+	if err != nil {
+		log.Println("❌ GetTOTP failed:", err)
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "Login is unavailable, try again later."})
+		return "", false
+	}
+	if totp == nil || !totp.Enabled {
+		return "", true
+	}
+	mfaToken, err := services.GenerateMFAToken(user.UserID)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token."})
+		return "", false
+	}
+	return mfaToken, true
+}
+
+// startSession Clears failed attempts and starts a session for a user who
+// passed every check
+func (a *App) startSession(c *gin.Context, user *models.User) (*services.SessionTokens, bool) {
+	if err := services.RecordLoginSuccess(a.DB, user.UserName); err != nil {
+		log.Println("❌ Clearing failed logins failed:", err)
+	}
+	// Start a session with an access and refresh token for user
+	tokens, err := services.StartSession(a.DB, user, c.Request.UserAgent())
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token."})
+		return nil, false
+	}
+	_ = a.auditAs(c, user.UserID, models.AuditLogin, user.UserID, tokens.SessionID.String())
+	return tokens, true
+}
+
+// LoginTOTP finishes the login of a user with two-factor authentication, the
+// mfa_token cookie from LoginUser proves the password was right
+func (a *App) LoginTOTP(c *gin.Context) {
+	var req models.TOTPCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
 		return
 	}
-	// Set token as a secure cookie and return success
-	// update: change for https, change path, change domain
-	c.SetCookie("token", token, 3600, "/", "", false, true)
+	mfaToken, _ := c.Cookie("mfa_token")
+	user, ok := a.checkLoginTOTP(c, mfaToken, &req)
+	if !ok {
+		return
+	}
+	c.SetCookie("mfa_token", "", -1, "/", "", false, true)
 
-	// c.Redirect(http.StatusSeeOther, "/dashboard")
+	tokens, ok := a.startSession(c, user)
+	if !ok {
+		return
+	}
+	middleware.SetSessionCookies(c, tokens)
 	c.IndentedJSON(http.StatusOK, gin.H{"message": "Login successful."})
 }
 
+// checkLoginTOTP Checks the second factor of a login against the user of the
+// mfa token, responding itself when it fails
+func (a *App) checkLoginTOTP(c *gin.Context, mfaToken string, req *models.TOTPCode) (*models.User, bool) {
+	userID, err := services.ParseMFAToken(mfaToken)
+	if err != nil {
+		c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "Your login has expired. Please login again."})
+		return nil, false
+	}
+	user := &models.User{UserID: userID}
+	if err := repositories.GetUser(a.DB, user); err != nil {
+		c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "Your login has expired. Please login again."})
+		return nil, false
+	}
+	// Wrong codes count towards the same lockout as wrong passwords
+	if a.loginLockedOut(c, user.UserName) {
+		return nil, false
+	}
+
+	totp, err := repositories.GetTOTP(a.DB, user.UserID)
+	if err != nil {
+		log.Println("❌ GetTOTP failed:", err)
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "Login is unavailable, try again later."})
+		return nil, false
+	}
+	if totp == nil || !totp.Enabled {
+		c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "Your login has expired. Please login again."})
+		return nil, false
+	}
+	err = services.VerifySecondFactor(a.DB, user.UserID, totp, req)
+	if errors.Is(err, services.ErrInvalidSecondFactor) {
+		if err := services.RecordLoginFailure(a.DB, user.UserName, c.ClientIP()); err != nil {
+			log.Println("❌ Recording failed login failed:", err)
+		}
+		_ = a.auditAs(c, uuid.Nil, models.AuditLoginFailed, user.UserID, "totp")
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Invalid authentication code."})
+		return nil, false
+	}
+	if err != nil {
+		log.Println("❌ VerifySecondFactor failed:", err)
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "Login is unavailable, try again later."})
+		return nil, false
+	}
+	return user, true
+}
+
+// RefreshToken trades the refresh token cookie for new session tokens
+func (a *App) RefreshToken(c *gin.Context) {
+	refreshToken, _ := c.Cookie("refresh_token")
+	tokens, err := services.RefreshSession(a.DB, refreshToken)
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		middleware.ClearSessionCookies(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("❌ Refreshing session failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session."})
+		return
+	}
+	middleware.SetSessionCookies(c, tokens)
+	c.JSON(http.StatusOK, gin.H{"message": "Session refreshed."})
+}
+
+// LogoutUser revokes the current session and clears its cookies
+func (a *App) LogoutUser(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	if sessionID, err := uuid.Parse(claims.SessionID); err == nil {
+		if _, err := repositories.RevokeSession(a.DB, sessionID); err != nil {
+			log.Println("❌ Revoking session failed:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out."})
+			return
+		}
+		if userID, err := uuid.Parse(claims.UserID); err == nil {
+			_ = a.audit(c, models.AuditLogout, userID, sessionID.String())
+		}
+	}
+	middleware.ClearSessionCookies(c)
+	c.Redirect(http.StatusFound, "/")
+}
+
 // CreateUser creates new User with hashed password and generated uuid
 func (a *App) CreateUser(c *gin.Context, user *models.User) {
 	// Check if username already exists in the database, slightly faster
@@ -75,6 +277,11 @@ func (a *App) CreateUser(c *gin.Context, user *models.User) {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Username already exists."})
 		return
 	}
+	// Email is optional, it is only used to reset a forgotten password
+	if err := user.CheckEmail(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	// Set user hashed user.InputPassword and UUID
 	if err := user.SetHashedPassword(); err != nil {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -82,10 +289,14 @@ func (a *App) CreateUser(c *gin.Context, user *models.User) {
 	}
 	user.SetUserID()
 	// Insert user into db
-	if err := repositories.CreateUser(a.DB, user); err != nil {
+	if err := repositories.CreateUser(a.DB, user); errors.Is(err, repositories.ErrEmailTaken) {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Email is already in use."})
+		return
+	} else if err != nil {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	_ = a.auditAs(c, user.UserID, models.AuditUserCreate, user.UserID, "")
 	// Set account created cookie and redirect to login
 	// c.SetCookie("accountStatus", "created", 5, "/login", "localhost", false, false)
 	// c.Redirect(http.StatusSeeOther, "/login")
@@ -115,5 +326,9 @@ func (a *App) GetCurrentUserInfo(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"firstName": user.FirstName,
+		"lastName":  user.LastName,
+		"username":  user.UserName,
+		"email":     user.Email,
+		"role":      user.Role,
 	})
 }
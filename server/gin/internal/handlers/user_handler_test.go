@@ -4,18 +4,23 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
 )
 
 type MockRow struct {
@@ -27,17 +32,36 @@ func (m *MockRow) Scan(dest ...any) error {
 	return args.Error(0)
 }
 
+// sqlContaining Matches queries by a part of their SQL
+func sqlContaining(part string) any {
+	return mock.MatchedBy(func(sql string) bool { return strings.Contains(sql, part) })
+}
+
+// mockLoginThrottle Mocks the lockout lookup and failure counting of a login
+// that is not locked out
+func mockLoginThrottle(mockDB *testutils.MockDB) {
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("MAX(locked_until)"), mock.Anything).Return(&testutils.MockRow{Values: []any{nil}})
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("RETURNING failures"), mock.Anything).Return(&testutils.MockRow{Values: []any{1}})
+	mockDB.On("Exec", mock.Anything, sqlContaining("login_throttle"), mock.Anything).Return(pgconn.NewCommandTag("DELETE 1"), nil)
+}
+
 func TestLoginUser_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	testutils.UseTestKeyRing(t)
 
 	mockDB := new(testutils.MockDB)
-	mockRow := new(MockRow)
+	testutils.MockAuditLog(mockDB)
+	mockLoginThrottle(mockDB)
 
-	// Setup the Scan behavior
-	mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
-
-	// Setup DB to return our MockRow
-	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+	// Setup DB to return the stored user
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("testpass"), bcrypt.DefaultCost)
+	mockDB.On("QueryRow", mock.Anything, "user_select_username_query", mock.Anything).Return(&testutils.MockRow{
+		Values: []any{"Test", "User", "testuser", uuid.New(), string(hashed)},
+	})
+	// No two-factor authentication
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM user_totp"), mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	// Session insert
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
 
 	app := &App{
 		DB: mockDB,
@@ -46,13 +70,10 @@ func TestLoginUser_Success(t *testing.T) {
 	router := gin.New()
 	router.POST("/", MakeUserHandler(app.LoginUser))
 
-	hashed, _ := bcrypt.GenerateFromPassword([]byte("testpass"), bcrypt.DefaultCost)
-
 	// Mock user login request
 	user := models.User{
-		UserName:       "testuser",
-		InputPassword:  "testpass",
-		HashedPassword: string(hashed),
+		UserName:      "testuser",
+		InputPassword: "testpass",
 	}
 	payload, _ := json.Marshal(user)
 
@@ -62,17 +83,138 @@ func TestLoginUser_Success(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
+	cookies := map[string]string{}
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	assert.NotEmpty(t, cookies["token"])
+	assert.NotEmpty(t, cookies["refresh_token"])
 }
 
-func TestLoginUser_BadPassword(t *testing.T) {
+func TestLoginUser_TOTPRequired(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	testutils.UseTestKeyRing(t)
 
 	mockDB := new(testutils.MockDB)
-	mockRow := new(MockRow)
+	mockLoginThrottle(mockDB)
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("testpass"), bcrypt.MinCost)
+	mockDB.On("QueryRow", mock.Anything, "user_select_username_query", mock.Anything).Return(&testutils.MockRow{
+		Values: []any{"Test", "User", "testuser", uuid.New(), string(hashed)},
+	})
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM user_totp"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", true, int64(0)},
+	})
+	app := &App{DB: mockDB}
 
-	mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	router := gin.New()
+	router.POST("/", MakeUserHandler(app.LoginUser))
 
-	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+	payload, _ := json.Marshal(models.User{UserName: "testuser", InputPassword: "testpass"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"mfaRequired": true`)
+	cookies := map[string]string{}
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	// No session until the code is checked
+	assert.NotEmpty(t, cookies["mfa_token"])
+	assert.Empty(t, cookies["token"])
+	assert.Empty(t, cookies["refresh_token"])
+}
+
+// setupLoginTOTP Routes POST /login/totp for a user with TOTP enabled, and
+// returns the mfa_token cookie LoginUser would have set
+func setupLoginTOTP(t *testing.T, mockDB *testutils.MockDB, secret string) (*gin.Engine, *http.Cookie) {
+	gin.SetMode(gin.TestMode)
+	testutils.UseTestKeyRing(t)
+
+	userID := uuid.New()
+	mockLoginThrottle(mockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_userid_query", mock.Anything).Return(mockUserRow(userID, "testpass"))
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM user_totp"), mock.Anything).Return(&testutils.MockRow{
+		Values: []any{secret, true, int64(0)},
+	})
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.POST("/login/totp", app.LoginTOTP)
+
+	mfaToken, err := services.GenerateMFAToken(userID)
+	assert.NoError(t, err)
+	return router, &http.Cookie{Name: "mfa_token", Value: mfaToken}
+}
+
+func TestLoginTOTP_Success(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	router, mfaCookie := setupLoginTOTP(t, mockDB, secret)
+	// Code step and session insert
+	mockDB.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+	code, _ := services.TOTPCode(secret, services.TOTPStep(time.Now()))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/login/totp", strings.NewReader(`{"code": "`+code+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(mfaCookie)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	cookies := map[string]string{}
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	assert.NotEmpty(t, cookies["token"])
+	assert.NotEmpty(t, cookies["refresh_token"])
+	assert.Empty(t, cookies["mfa_token"])
+}
+
+func TestLoginTOTP_InvalidCode(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	router, mfaCookie := setupLoginTOTP(t, mockDB, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/login/totp", strings.NewReader(`{"code": "abcdef"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(mfaCookie)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid authentication code.")
+	// Wrong codes count as failed logins
+	mockDB.AssertCalled(t, "QueryRow", mock.Anything, sqlContaining("RETURNING failures"), mock.Anything)
+}
+
+func TestLoginTOTP_MissingToken(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	router, _ := setupLoginTOTP(t, mockDB, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/login/totp", strings.NewReader(`{"code": "123456"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockDB.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLoginUser_BadPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	mockLoginThrottle(mockDB)
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("testpass"), bcrypt.MinCost)
+	mockDB.On("QueryRow", mock.Anything, "user_select_username_query", mock.Anything).Return(&testutils.MockRow{
+		Values: []any{"Test", "User", "testuser", uuid.New(), string(hashed)},
+	})
 
 	app := &App{
 		DB: mockDB,
@@ -82,9 +224,8 @@ func TestLoginUser_BadPassword(t *testing.T) {
 	router.POST("/", MakeUserHandler(app.LoginUser))
 
 	user := models.User{
-		UserName:       "testuser",
-		InputPassword:  "wrongpass",                                                    // Wrong password
-		HashedPassword: "$2a$10$7bKjklasd09u4Yh7p5hFfuzpsN..EWD0KCRJJJKfVq3LUV1Unv7me", // bcrypt-hash of "testpass"
+		UserName:      "testuser",
+		InputPassword: "wrongpass", // Wrong password
 	}
 	payload, _ := json.Marshal(user)
 
@@ -94,6 +235,88 @@ func TestLoginUser_BadPassword(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, 400, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid username or password.")
+	// Failure counted for the username and the IP, and audited
+	mockDB.AssertNumberOfCalls(t, "QueryRow", 5)
+}
+
+func TestLoginUser_UnknownUsernameSameError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockDB := new(testutils.MockDB)
+	mockLoginThrottle(mockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_username_query", mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.POST("/", MakeUserHandler(app.LoginUser))
+
+	// A client sent hash must not be trusted for an unknown user
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	payload, _ := json.Marshal(models.User{UserName: "nobody", InputPassword: "pass", HashedPassword: string(hashed)})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid username or password.")
+	// Audited without a subject, there is no such user
+	mockDB.AssertCalled(t, "QueryRow", mock.Anything, sqlContaining("INSERT INTO audit_events"), mock.MatchedBy(func(args []any) bool {
+		return args[1].(*uuid.UUID) == nil && args[2] == models.AuditLoginFailed
+	}))
+}
+
+func TestLoginUser_IgnoresClientUserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockDB := new(testutils.MockDB)
+	mockLoginThrottle(mockDB)
+	testutils.MockAuditLog(mockDB)
+	mockDB.On("QueryRow", mock.Anything, "user_select_username_query", mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	mockDB.On("QueryRow", mock.Anything, "user_select_userid_query", mock.Anything).Return(mockUserRow(uuid.New(), "testpass"))
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.POST("/", MakeUserHandler(app.LoginUser))
+
+	// Guessing a victim's password by their userid must count against the
+	// username that was checked for a lockout
+	payload, _ := json.Marshal(models.User{UserName: "random", UserID: uuid.New(), InputPassword: "testpass"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	mockDB.AssertNotCalled(t, "QueryRow", mock.Anything, "user_select_userid_query", mock.Anything)
+	mockDB.AssertCalled(t, "QueryRow", mock.Anything, sqlContaining("RETURNING failures"), mock.MatchedBy(func(args []any) bool {
+		return args[0] == "user:random"
+	}))
+}
+
+func TestLoginUser_LockedOut(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockDB := new(testutils.MockDB)
+	until := time.Now().Add(90 * time.Second)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Values: []any{&until}})
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.POST("/", MakeUserHandler(app.LoginUser))
+
+	payload, _ := json.Marshal(models.User{UserName: "testuser", InputPassword: "testpass"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	// The password is never checked while locked out
+	mockDB.AssertNumberOfCalls(t, "QueryRow", 1)
 }
 
 func TestCreateUser_Success(t *testing.T) {
@@ -109,6 +332,8 @@ func TestCreateUser_Success(t *testing.T) {
 		mock.Anything,
 		mock.Anything,
 		mock.Anything,
+		mock.Anything,
+		mock.Anything,
 	).Return(sql.ErrNoRows)
 
 	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
@@ -152,6 +377,8 @@ func TestCreateUser_DuplicateUsername(t *testing.T) {
 		mock.Anything,
 		mock.Anything,
 		mock.Anything,
+		mock.Anything,
+		mock.Anything,
 	).Return(nil)
 
 	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
@@ -176,3 +403,75 @@ func TestCreateUser_DuplicateUsername(t *testing.T) {
 
 	assert.Equal(t, 400, w.Code) // Bad Request: duplicate username
 }
+
+func TestRefreshToken_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testutils.UseTestKeyRing(t)
+
+	mockDB := new(testutils.MockDB)
+	sessionID, userID := uuid.New(), uuid.New()
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{
+		Values: []any{sessionID, userID, time.Now(), time.Now().Add(time.Hour)},
+	})
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.POST("/auth/refresh", app.RefreshToken)
+
+	req, _ := http.NewRequest("POST", "/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "old-token"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	cookies := map[string]string{}
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	assert.NotEmpty(t, cookies["token"])
+	assert.NotEmpty(t, cookies["refresh_token"])
+	assert.NotEqual(t, "old-token", cookies["refresh_token"])
+}
+
+func TestRefreshToken_Invalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockDB := new(testutils.MockDB)
+	// Neither a current nor a just rotated token
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.POST("/auth/refresh", app.RefreshToken)
+
+	req, _ := http.NewRequest("POST", "/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "unknown"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogoutUser_RevokesSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockDB := new(testutils.MockDB)
+	testutils.MockAuditLog(mockDB)
+	sessionID := uuid.New()
+	mockDB.On("Exec", mock.Anything, mock.Anything, []any{sessionID}).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+	app := &App{DB: mockDB}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: uuid.New().String(), SessionID: sessionID.String()})
+		c.Next()
+	})
+	router.GET("/dashboard/logout", app.LogoutUser)
+
+	req, _ := http.NewRequest("GET", "/dashboard/logout", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	mockDB.AssertExpectations(t)
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- LogMailer: keeps sent messages and writes .eml files to Dir
+//- format(): header injection through line breaks is stripped
+//- FromEnv(): picks SMTP only when SMTP_HOST is set
+
+func TestLogMailer_WritesFile(t *testing.T) {
+	dir := t.TempDir()
+	m := &LogMailer{Dir: dir}
+
+	err := m.Send(context.Background(), Message{To: "ada@example.com", Subject: "Hello", Body: "line 1\nline 2"})
+	assert.NoError(t, err)
+	assert.Len(t, m.Sent(), 1)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.Len(t, files, 1)
+	data, _ := os.ReadFile(files[0])
+	assert.Contains(t, string(data), "To: ada@example.com\r\n")
+	assert.Contains(t, string(data), "\r\n\r\nline 1\r\nline 2")
+}
+
+func TestFormat_StripsHeaderInjection(t *testing.T) {
+	data := string(format("a@example.com", Message{To: "b@example.com\r\nBcc: c@example.com", Subject: "Hi"}))
+
+	headers, _, _ := strings.Cut(data, "\r\n\r\n")
+	assert.NotContains(t, headers, "\r\nBcc:")
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	_, ok := FromEnv().(*LogMailer)
+	assert.True(t, ok)
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "")
+	m, ok := FromEnv().(*SMTPMailer)
+	assert.True(t, ok)
+	assert.Equal(t, "smtp.example.com:587", m.Addr)
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsMaxAge is how many seconds browsers may cache a preflight response
+const corsMaxAge = "600"
+
+// CORSOriginsFromEnv Returns the comma separated origins of
+// CORS_ALLOWED_ORIGINS, e.g. "https://app.kayphos.com,http://localhost:19006"
+func CORSOriginsFromEnv() []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// CORSMiddleware Lets browser clients on the allowed origins call the routes
+// under the given path prefixes. "*" allows every origin. Credentials are not
+// allowed, cross origin clients authenticate with bearer tokens while the
+// same origin web app keeps its cookies
+func CORSMiddleware(allowedOrigins []string, prefixes ...string) gin.HandlerFunc {
+	allowAll := false
+	allowed := map[string]struct{}{}
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[origin] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || !hasAnyPrefix(c.Request.URL.Path, prefixes) {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		if _, ok := allowed[origin]; !ok && !allowAll {
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Access-Control-Expose-Headers", "Retry-After, WWW-Authenticate")
+		// Answer preflights here, they carry no token and have no route
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, "+PatientContextHeader)
+			c.Header("Access-Control-Max-Age", corsMaxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
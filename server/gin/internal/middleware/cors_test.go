@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- CORSMiddleware(): allowed origins get CORS headers on the API prefixes only
+//- Preflights are answered without reaching the token middleware
+//- CORSOriginsFromEnv(): splits and trims CORS_ALLOWED_ORIGINS
+
+func setupCORSRouter(origins ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CORSMiddleware(origins, "/api/"))
+	r.GET("/api/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/page", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestCORSMiddleware_AllowedOrigin(t *testing.T) {
+	r := setupCORSRouter("https://app.kayphos.com")
+
+	req, _ := http.NewRequest("GET", "/api/test", nil)
+	req.Header.Set("Origin", "https://app.kayphos.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.kayphos.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Pages outside the prefixes are same origin only
+	req, _ = http.NewRequest("GET", "/page", nil)
+	req.Header.Set("Origin", "https://app.kayphos.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSMiddleware_OtherOrigin(t *testing.T) {
+	r := setupCORSRouter("https://app.kayphos.com")
+
+	req, _ := http.NewRequest("GET", "/api/test", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+}
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	r := setupCORSRouter("*")
+
+	// No OPTIONS route exists, the preflight is answered by the middleware
+	req, _ := http.NewRequest("OPTIONS", "/api/test", nil)
+	req.Header.Set("Origin", "http://localhost:19006")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "http://localhost:19006", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+}
+
+func TestCORSOriginsFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://a.example.com, ,http://localhost:19006")
+	assert.Equal(t, []string{"https://a.example.com", "http://localhost:19006"}, CORSOriginsFromEnv())
+}
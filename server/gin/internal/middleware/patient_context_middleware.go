@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// PatientContextHeader names the patient a caregiver is acting for
+const PatientContextHeader = "X-Patient-ID"
+
+// PatientContextMiddleware Lets a caregiver act for a patient who linked
+// them by sending the patient's ID in the X-Patient-ID header. Only the
+// routes listed, keyed like "POST /dashboard/api/user-meal-history", can be
+// called for a patient. The claims then name the patient as the user and the
+// caregiver as the actor, and every such request is audited. Runs after
+// ValidateTokenMiddleware
+func PatientContextMiddleware(db repositories.DBClient, routes map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(PatientContextHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+		claims := c.MustGet("claims").(*models.Claims)
+		patientID, err := uuid.Parse(header)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid " + PatientContextHeader + " header"})
+			return
+		}
+		if patientID.String() == claims.UserID {
+			c.Next()
+			return
+		}
+		route := c.Request.Method + " " + c.FullPath()
+		if claims.IsAPIToken() || !routes[route] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint can't be used for a patient."})
+			return
+		}
+
+		caregiverID, err := uuid.Parse(claims.UserID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user token"})
+			return
+		}
+		linked, err := repositories.HasPatientLink(db, patientID, caregiverID, models.LinkCaregiver)
+		if err != nil {
+			log.Printf("❌ HasPatientLink failed: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check access to patient"})
+			return
+		}
+		if !linked {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You don't have access to this patient."})
+			return
+		}
+
+		// Nothing happens for a patient without a record of it
+		err = repositories.InsertAuditEvent(db, &models.AuditEvent{
+			ActorID:    &caregiverID,
+			SubjectID:  &patientID,
+			Action:     models.AuditCaregiverRequest,
+			ResourceID: route,
+			IP:         c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+		})
+		if err != nil {
+			log.Printf("❌ Writing audit event failed: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check access to patient"})
+			return
+		}
+
+		acting := *claims
+		acting.UserID = patientID.String()
+		acting.ActorID = caregiverID.String()
+		c.Set("claims", &acting)
+		c.Next()
+	}
+}
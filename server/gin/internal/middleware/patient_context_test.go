@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- PatientContextMiddleware(): linked caregivers act for the patient on the
+//  listed routes only, and each request is audited
+
+func sqlContaining(part string) any {
+	return mock.MatchedBy(func(sql string) bool { return strings.Contains(sql, part) })
+}
+
+func setupPatientContextRouter(mockDB *testutils.MockDB, caregiverID uuid.UUID, seen *models.Claims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", &models.Claims{UserID: caregiverID.String()})
+		c.Next()
+	})
+	r.Use(PatientContextMiddleware(mockDB, map[string]bool{"POST /meals": true}))
+	handler := func(c *gin.Context) {
+		*seen = *c.MustGet("claims").(*models.Claims)
+		c.Status(http.StatusOK)
+	}
+	r.POST("/meals", handler)
+	r.DELETE("/account", handler)
+	return r
+}
+
+func TestPatientContextMiddleware_Linked(t *testing.T) {
+	caregiverID, patientID := uuid.New(), uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM patient_links"), mock.Anything).Return(&testutils.MockRow{Values: []any{true}})
+	mockDB.On("QueryRow", mock.Anything, sqlContaining("INSERT INTO audit_events"), mock.Anything).Return(&testutils.MockRow{Values: []any{int64(1), time.Now()}})
+	var seen models.Claims
+	r := setupPatientContextRouter(mockDB, caregiverID, &seen)
+
+	req, _ := http.NewRequest("POST", "/meals", nil)
+	req.Header.Set(PatientContextHeader, patientID.String())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, patientID.String(), seen.UserID)
+	assert.Equal(t, caregiverID.String(), seen.Actor())
+	mockDB.AssertCalled(t, "QueryRow", mock.Anything, sqlContaining("INSERT INTO audit_events"), mock.Anything)
+}
+
+func TestPatientContextMiddleware_Denied(t *testing.T) {
+	tests := map[string]struct {
+		method, path string
+		linked       bool
+	}{
+		"not linked":        {"POST", "/meals", false},
+		"route not allowed": {"DELETE", "/account", true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockDB := new(testutils.MockDB)
+			mockDB.On("QueryRow", mock.Anything, sqlContaining("FROM patient_links"), mock.Anything).Return(&testutils.MockRow{Values: []any{tt.linked}})
+			var seen models.Claims
+			r := setupPatientContextRouter(mockDB, uuid.New(), &seen)
+
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(PatientContextHeader, uuid.NewString())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Empty(t, seen.UserID)
+		})
+	}
+}
+
+func TestPatientContextMiddleware_NoHeader(t *testing.T) {
+	caregiverID := uuid.New()
+	mockDB := new(testutils.MockDB)
+	var seen models.Claims
+	r := setupPatientContextRouter(mockDB, caregiverID, &seen)
+
+	req, _ := http.NewRequest("DELETE", "/account", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, caregiverID.String(), seen.UserID)
+	assert.Empty(t, seen.ActorID)
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// RequireRole Lets the request through when the user has one of roles. The
+// role is read on every request so a change applies to live sessions. Runs
+// after ValidateTokenMiddleware
+func RequireRole(db repositories.DBClient, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("claims").(*models.Claims)
+		userID, err := uuid.Parse(claims.UserID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user token"})
+			return
+		}
+		user := &models.User{UserID: userID}
+		if err := repositories.GetUser(db, user); err != nil {
+			log.Printf("❌ Loading role of %s failed: %v", userID, err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user token"})
+			return
+		}
+		for _, role := range roles {
+			if user.Role == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Your account doesn't have access to this endpoint."})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- RequireRole(): users with the role get through, others get 403
+
+func TestRequireRole(t *testing.T) {
+	for role, want := range map[string]int{
+		models.RoleClinician: http.StatusOK,
+		models.RolePatient:   http.StatusForbidden,
+		models.RoleAdmin:     http.StatusForbidden,
+	} {
+		t.Run(role, func(t *testing.T) {
+			userID := uuid.New()
+			mockDB := new(testutils.MockDB)
+			mockDB.On("QueryRow", mock.Anything, "user_select_userid_query", mock.Anything).Return(&testutils.MockRow{
+				Values: []any{"Grace", "Hopper", "drhopper", userID, "hash", "", role},
+			})
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("claims", &models.Claims{UserID: userID.String()})
+				c.Next()
+			})
+			r.Use(RequireRole(mockDB, models.RoleClinician))
+			r.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, want, w.Code)
+		})
+	}
+}
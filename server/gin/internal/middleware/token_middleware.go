@@ -1,49 +1,184 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
-	"net/http"
-	"os"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
 )
 
-func ValidateTokenMiddleware() gin.HandlerFunc {
+// ValidateTokenMiddleware Lets the request through with a valid access token
+// of a live session. When the access token is missing or expired the refresh
+// token is used to continue the session, so users are not logged out while
+// they are using the app. API clients send the access token in an
+// Authorization: Bearer header instead and refresh it themselves
+func ValidateTokenMiddleware(db repositories.DBClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		claims := &models.Claims{}
+		if tokenString, ok := bearerToken(c); ok {
+			if services.IsAPIToken(tokenString) {
+				checkAPIToken(c, db, tokenString)
+				return
+			}
+			parsedToken, err := parseToken(tokenString, claims)
+			if err != nil || !parsedToken.Valid {
+				handleUnauthorized(c, "Invalid or expired access token")
+				return
+			}
+			checkSession(c, db, claims)
+			return
+		}
+
 		tokenString, err := c.Cookie("token")
+		if err == nil {
+			parsedToken, err := parseToken(tokenString, claims)
+			if err == nil && parsedToken.Valid {
+				checkSession(c, db, claims)
+				return
+			}
+		}
+
+		// Access token is missing or stale, try to refresh the session
+		refreshToken, err := c.Cookie("refresh_token")
 		if err != nil {
 			handleUnauthorized(c, "Missing token")
 			return
 		}
-
-		claims := &models.Claims{}
-		parsedToken, err := parseToken(tokenString, claims)
-		if err != nil || !parsedToken.Valid {
+		tokens, err := services.RefreshSession(db, refreshToken)
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			ClearSessionCookies(c)
 			handleUnauthorized(c, "Your session is invalid or has expired. Please login again.")
 			return
 		}
+		if err != nil {
+			log.Println("❌ Refreshing session failed:", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session."})
+			return
+		}
+		SetSessionCookies(c, tokens)
 
 		// ✅ Passed all checks
-		c.Set("claims", claims)
+		c.Set("claims", tokens.Claims())
 		c.Next()
 	}
 }
 
+// checkSession Rejects access tokens whose session was revoked by logout
+func checkSession(c *gin.Context, db repositories.DBClient, claims *models.Claims) {
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		handleUnauthorized(c, "Your session is invalid or has expired. Please login again.")
+		return
+	}
+	active, err := repositories.IsSessionActive(db, sessionID)
+	if err != nil {
+		log.Println("❌ Checking session failed:", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session."})
+		return
+	}
+	if !active {
+		if _, ok := bearerToken(c); !ok {
+			ClearSessionCookies(c)
+		}
+		handleUnauthorized(c, "Your session has ended. Please login again.")
+		return
+	}
+
+	// ✅ Passed all checks
+	c.Set("claims", claims)
+	c.Next()
+}
+
+// checkAPIToken Lets through requests with a live personal access token, the
+// scopes are checked per route by APITokenScopeMiddleware
+func checkAPIToken(c *gin.Context, db repositories.DBClient, token string) {
+	claims, err := services.AuthenticateAPIToken(db, token)
+	if errors.Is(err, services.ErrInvalidAPIToken) {
+		handleUnauthorized(c, err.Error())
+		return
+	}
+	if err != nil {
+		log.Println("❌ Checking API token failed:", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify API token."})
+		return
+	}
+
+	// ✅ Passed all checks
+	c.Set("claims", claims)
+	c.Next()
+}
+
+// APITokenScopeMiddleware Limits personal access tokens to the routes in
+// routeScopes, keyed by method and route path like
+// "GET /dashboard/api/targets", and to the scope each route needs. Requests
+// with a session are not limited
+func APITokenScopeMiddleware(routeScopes map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.MustGet("claims").(*models.Claims)
+		if !ok || !claims.IsAPIToken() {
+			c.Next()
+			return
+		}
+		scope, ok := routeScopes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint can't be used with an API token."})
+			return
+		}
+		if !claims.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API token is missing the " + scope + " scope."})
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetSessionCookies Stores the access token, and the refresh token when there
+// is a new one, as http only cookies
+func SetSessionCookies(c *gin.Context, tokens *services.SessionTokens) {
+	// update: change for https, change path, change domain
+	c.SetCookie("token", tokens.AccessToken, int(services.AccessTokenTTL.Seconds()), "/", "", false, true)
+	if tokens.RefreshToken != "" {
+		c.SetCookie("refresh_token", tokens.RefreshToken, int(services.RefreshTokenTTL.Seconds()), "/", "", false, true)
+	}
+}
+
+// ClearSessionCookies Removes both session cookies
+func ClearSessionCookies(c *gin.Context) {
+	c.SetCookie("token", "", -1, "/", "", false, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+}
+
 // --- Helper to parse the token ---
 func parseToken(tokenString string, claims *models.Claims) (*jwt.Token, error) {
-	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		secret := os.Getenv("JWT_SECRET")
-		return []byte(secret), nil
-	})
+	return services.ParseToken(tokenString, claims)
+}
+
+// bearerToken Returns the token of an Authorization: Bearer header
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
 }
 
 // --- Helper to handle unauthorized responses ---
 func handleUnauthorized(c *gin.Context, message string) {
 	c.Abort()
+	// API clients get JSON and the RFC 6750 challenge
+	if _, ok := bearerToken(c); ok {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+		return
+	}
 	if gin.Mode() == gin.TestMode {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": message})
 		return
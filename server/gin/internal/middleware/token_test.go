@@ -9,7 +9,10 @@
 // ✅ Token creation with valid claims
 // ✅ Middleware passes with good token
 // ✅ Middleware blocks missing or invalid token
-// ✅ Expired token behavior (could add later)
+// ✅ Expired token behavior
+// ✅ Revoked sessions are blocked, expired access tokens are refreshed
+// ✅ Authorization: Bearer tokens, which are never refreshed from cookies
+// ✅ Personal access tokens are limited to the scoped routes
 //
 // 🧪 What’s NOT Covered
 // ❌ Full login/signup flow (separate test)
@@ -21,45 +24,45 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
 	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 )
 
 func TestGenerateToken(t *testing.T) {
-	err := os.Setenv("JWT_SECRET", "testsecret")
-	if err != nil {
-		return
-	}
+	testutils.UseTestKeyRing(t)
 
 	mockUser := &models.User{
 		UserID: uuid.New(),
 	}
-	token, err := services.GenerateToken(mockUser)
+	token, err := services.GenerateToken(mockUser, uuid.New())
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 }
 
 func TestValidateTokenMiddleware_Success(t *testing.T) {
-	err := os.Setenv("JWT_SECRET", "testsecret")
-	if err != nil {
-		return
-	}
+	testutils.UseTestKeyRing(t)
 
 	// Create token
 	mockUser := &models.User{UserID: uuid.New()}
-	token, _ := services.GenerateToken(mockUser)
+	token, _ := services.GenerateToken(mockUser, uuid.New())
+
+	// Session is live
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Values: []any{true}})
 
 	// Setup Gin
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	r.Use(ValidateTokenMiddleware())
+	r.Use(ValidateTokenMiddleware(mockDB))
 	r.GET("/test", func(c *gin.Context) {
 		c.Status(http.StatusOK)
 	})
@@ -73,14 +76,11 @@ func TestValidateTokenMiddleware_Success(t *testing.T) {
 }
 
 func TestValidateTokenMiddleware_MissingToken(t *testing.T) {
-	err := os.Setenv("JWT_SECRET", "testsecret")
-	if err != nil {
-		return
-	}
+	testutils.UseTestKeyRing(t)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	r.Use(ValidateTokenMiddleware())
+	r.Use(ValidateTokenMiddleware(nil))
 	r.GET("/test", func(c *gin.Context) {
 		c.Status(http.StatusOK)
 	})
@@ -93,14 +93,11 @@ func TestValidateTokenMiddleware_MissingToken(t *testing.T) {
 }
 
 func TestValidateTokenMiddleware_InvalidToken(t *testing.T) {
-	err := os.Setenv("JWT_SECRET", "testsecret")
-	if err != nil {
-		return
-	}
+	testutils.UseTestKeyRing(t)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	r.Use(ValidateTokenMiddleware())
+	r.Use(ValidateTokenMiddleware(nil))
 	r.GET("/test", func(c *gin.Context) {
 		c.Status(http.StatusOK)
 	})
@@ -114,10 +111,7 @@ func TestValidateTokenMiddleware_InvalidToken(t *testing.T) {
 }
 
 func TestValidateTokenMiddleware_ExpiredToken(t *testing.T) {
-	err := os.Setenv("JWT_SECRET", "testsecret")
-	if err != nil {
-		return
-	}
+	testutils.UseTestKeyRing(t)
 
 	expiredClaims := models.Claims{
 		UserID: uuid.New().String(),
@@ -126,13 +120,12 @@ func TestValidateTokenMiddleware_ExpiredToken(t *testing.T) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, expiredClaims)
-	tokenString, _ := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokenString, _ := services.SignToken(expiredClaims)
 
 	// Setup Gin
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	r.Use(ValidateTokenMiddleware())
+	r.Use(ValidateTokenMiddleware(nil))
 	r.GET("/test", func(c *gin.Context) {
 		c.Status(http.StatusOK)
 	})
@@ -144,3 +137,173 @@ func TestValidateTokenMiddleware_ExpiredToken(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestValidateTokenMiddleware_RevokedSession(t *testing.T) {
+	testutils.UseTestKeyRing(t)
+
+	token, _ := services.GenerateToken(&models.User{UserID: uuid.New()}, uuid.New())
+
+	// Session was logged out
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Values: []any{false}})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateTokenMiddleware(mockDB))
+	r.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestValidateTokenMiddleware_RefreshesExpiredToken(t *testing.T) {
+	testutils.UseTestKeyRing(t)
+
+	sessionID, userID := uuid.New(), uuid.New()
+	tokenString, _ := services.SignToken(models.Claims{
+		UserID:    userID.String(),
+		SessionID: sessionID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-1 * time.Minute)),
+		},
+	})
+
+	// Refresh token rotates
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{
+		Values: []any{sessionID, userID, time.Now(), time.Now().Add(time.Hour)},
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateTokenMiddleware(mockDB))
+	var claims *models.Claims
+	r.GET("/test", func(c *gin.Context) {
+		claims = c.MustGet("claims").(*models.Claims)
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: tokenString})
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, userID.String(), claims.UserID)
+	assert.Equal(t, sessionID.String(), claims.SessionID)
+	assert.Len(t, w.Result().Cookies(), 2)
+}
+
+func TestValidateTokenMiddleware_BearerToken(t *testing.T) {
+	testutils.UseTestKeyRing(t)
+
+	token, _ := services.GenerateToken(&models.User{UserID: uuid.New()}, uuid.New())
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Values: []any{true}})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateTokenMiddleware(mockDB))
+	r.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+}
+
+func TestValidateTokenMiddleware_ExpiredBearerToken(t *testing.T) {
+	testutils.UseTestKeyRing(t)
+
+	tokenString, _ := services.SignToken(models.Claims{
+		UserID:    uuid.New().String(),
+		SessionID: uuid.New().String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-1 * time.Minute)),
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateTokenMiddleware(new(testutils.MockDB)))
+	r.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// A refresh cookie alongside a bearer token is ignored
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "invalid_token")
+	assert.Empty(t, w.Result().Cookies())
+}
+
+func TestValidateTokenMiddleware_APIToken(t *testing.T) {
+	tokenID, userID := uuid.New(), uuid.New()
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{
+		Values: []any{tokenID, userID, []string{models.ScopeMealsRead}},
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateTokenMiddleware(mockDB))
+	r.Use(APITokenScopeMiddleware(map[string]string{
+		"GET /api/meals/:id": models.ScopeMealsRead,
+		"PUT /api/meals/:id": models.ScopeMealsWrite,
+	}))
+	r.GET("/api/meals/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.PUT("/api/meals/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/account", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := map[string]struct {
+		method, path string
+		want         int
+	}{
+		"scope granted":    {"GET", "/api/meals/1", http.StatusOK},
+		"scope missing":    {"PUT", "/api/meals/1", http.StatusForbidden},
+		"route not listed": {"GET", "/api/account", http.StatusForbidden},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+services.APITokenPrefix+"secret")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestValidateTokenMiddleware_RevokedAPIToken(t *testing.T) {
+	mockDB := new(testutils.MockDB)
+	mockDB.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(&testutils.MockRow{Err: pgx.ErrNoRows})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateTokenMiddleware(mockDB))
+	r.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+services.APITokenPrefix+"revoked")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,266 @@
+package migrations
+
+/*
+ * Numbered schema migrations embedded in the binary. Applied versions are
+ * recorded in schema_migrations, each migration runs in its own transaction
+ */
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockID keeps two servers starting at once from migrating the same database
+const lockID = 7243310
+
+// Conn is the part of pgx.Conn and pgxpool.Pool the migrator uses
+type Conn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Migration is one numbered schema change read from sql/NNNN_name.up.sql and
+// sql/NNNN_name.down.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and when it was applied, nil if it is pending
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load Reads the embedded migrations sorted by version
+func Load() ([]Migration, error) {
+	return load(files, "sql")
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		version, name, direction, err := parseName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %04d has two names, %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", m.Version, m.Name)
+		}
+		if m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %04d is missing", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// parseName Splits "0002_meals.up.sql" into 2, "meals" and "up"
+func parseName(file string) (int, string, string, error) {
+	base, ok := strings.CutSuffix(file, ".sql")
+	if !ok {
+		return 0, "", "", fmt.Errorf("%s: not a .sql file", file)
+	}
+	dot := strings.LastIndex(base, ".")
+	if dot == -1 {
+		return 0, "", "", fmt.Errorf("%s: expected NNNN_name.up.sql or NNNN_name.down.sql", file)
+	}
+	base, direction := base[:dot], base[dot+1:]
+	if direction != "up" && direction != "down" {
+		return 0, "", "", fmt.Errorf("%s: expected NNNN_name.up.sql or NNNN_name.down.sql", file)
+	}
+	num, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("%s: expected NNNN_name.up.sql or NNNN_name.down.sql", file)
+	}
+	version, err := strconv.Atoi(num)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("%s: bad version %q", file, num)
+	}
+	return version, name, direction, nil
+}
+
+// Up Applies every pending migration in order and returns the ones it applied
+func Up(ctx context.Context, db Conn) ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		ok, err := run(ctx, db, m, true)
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		if ok {
+			log.Printf("🗄️ Applied migration %04d_%s", m.Version, m.Name)
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// Down Reverts the last steps applied migrations, newest first
+func Down(ctx context.Context, db Conn, steps int) ([]Migration, error) {
+	statuses, err := Statuses(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(statuses) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := statuses[i]
+		if m.AppliedAt == nil {
+			continue
+		}
+		ok, err := run(ctx, db, m.Migration, false)
+		if err != nil {
+			return reverted, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		if ok {
+			log.Printf("🗄️ Reverted migration %04d_%s", m.Version, m.Name)
+			reverted = append(reverted, m.Migration)
+		}
+	}
+	return reverted, nil
+}
+
+// Statuses Lists every migration and when it was applied
+func Statuses(ctx context.Context, db Conn) ([]Status, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, `SELECT version, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+	appliedAt := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		appliedAt[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = Status{Migration: m}
+		if at, ok := appliedAt[m.Version]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+func ensureTable(ctx context.Context, db Conn) error {
+	_, err := db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`)
+	return err
+}
+
+// run Applies or reverts one migration. It reports false without changing
+// anything when another process already did
+func run(ctx context.Context, db Conn, m Migration, up bool) (bool, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	// No-op once committed
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1);`, lockID); err != nil {
+		return false, err
+	}
+	var applied bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1);`, m.Version).Scan(&applied)
+	if err != nil {
+		return false, err
+	}
+	if applied == up {
+		return false, nil
+	}
+
+	if up {
+		if _, err := tx.Exec(ctx, m.Up); err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2);`, m.Version, m.Name)
+	} else {
+		if _, err := tx.Exec(ctx, m.Down); err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1;`, m.Version)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- Load(): embedded migrations are numbered without gaps and reversible
+//- load(): rejects badly named, unpaired and missing migrations
+
+func TestLoad_Embedded(t *testing.T) {
+	migrations, err := Load()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	assert.Equal(t, "users", migrations[0].Name)
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version)
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name": {
+			"sql/users.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"no down file": {
+			"sql/0001_users.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"gap": {
+			"sql/0001_users.up.sql":   {Data: []byte("SELECT 1;")},
+			"sql/0001_users.down.sql": {Data: []byte("SELECT 1;")},
+			"sql/0003_meals.up.sql":   {Data: []byte("SELECT 1;")},
+			"sql/0003_meals.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := load(fsys, "sql")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseName(t *testing.T) {
+	version, name, direction, err := parseName("0002_fndds_versions.down.sql")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.Equal(t, "fndds_versions", name)
+	assert.Equal(t, "down", direction)
+}
@@ -1,7 +1,7 @@
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS users (
     first_name      VARCHAR(100) NOT NULL,
     last_name       VARCHAR(100) NOT NULL,
     user_name       VARCHAR(100) UNIQUE NOT NULL,
-    user_id 	    UUID PRIMARY KEY,
+    user_id         UUID PRIMARY KEY,
     hashed_password TEXT NOT NULL
 );
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS meals (
+    id            SERIAL PRIMARY KEY,
+    user_id       UUID NOT NULL REFERENCES users(user_id),
+    meal_name     TEXT NOT NULL,
+    time          TIMESTAMPTZ DEFAULT now(),
+    meal_type     TEXT CHECK (meal_type IN ('favorite', 'history')) NOT NULL,
+    ingredients   JSONB NOT NULL,
+    totals        JSONB NOT NULL,
+    -- FNDDS release the totals were computed from, NULL for meals with no
+    -- verified ingredients
+    fndds_version TEXT
+);
+
+ALTER TABLE meals ADD COLUMN IF NOT EXISTS fndds_version TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_meals_user_id ON meals(user_id);
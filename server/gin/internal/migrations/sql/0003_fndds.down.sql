@@ -0,0 +1,5 @@
+-- Drops every imported FNDDS release, run cmd/fndds-import again after
+-- migrating back up
+DROP TABLE IF EXISTS fndds_portions_weights;
+DROP TABLE IF EXISTS fndds_nutrient_values;
+DROP TABLE IF EXISTS fndds_datasets;
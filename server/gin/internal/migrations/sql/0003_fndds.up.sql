@@ -0,0 +1,66 @@
+-- FNDDS releases live side by side, keyed by dataset version. The data itself
+-- is loaded by cmd/fndds-import. Databases seeded with the old csvsql scripts
+-- already have the tables, those are upgraded in place as the 2021-2023
+-- release
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS fndds_datasets (
+    version     TEXT PRIMARY KEY,
+    source_file TEXT NOT NULL,
+    food_count  INTEGER NOT NULL,
+    imported_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    active      BOOLEAN NOT NULL DEFAULT false
+);
+ALTER TABLE fndds_datasets ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT false;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fndds_datasets_active ON fndds_datasets (active) WHERE active;
+
+CREATE TABLE IF NOT EXISTS fndds_nutrient_values (
+    dataset_version              TEXT NOT NULL,
+    "Food code"                  numeric NOT NULL,
+    "Main food description"      varchar NOT NULL,
+    "WWEIA Category number"      numeric,
+    "WWEIA Category description" varchar,
+    "Energy (kcal)"              numeric,
+    "Protein (g)"                numeric,
+    "Carbohydrate (g)"           numeric,
+    "Potassium (mg)"             numeric,
+    "Phosphorus (mg)"            numeric,
+    description                  tsvector,
+    PRIMARY KEY (dataset_version, "Food code")
+);
+ALTER TABLE fndds_nutrient_values ADD COLUMN IF NOT EXISTS description tsvector;
+ALTER TABLE fndds_nutrient_values ADD COLUMN IF NOT EXISTS dataset_version TEXT NOT NULL DEFAULT '2021-2023';
+ALTER TABLE fndds_nutrient_values ALTER COLUMN dataset_version DROP DEFAULT;
+ALTER TABLE fndds_nutrient_values DROP CONSTRAINT IF EXISTS unique_food_code;
+ALTER TABLE fndds_nutrient_values DROP CONSTRAINT IF EXISTS fndds_nutrient_values_pkey;
+ALTER TABLE fndds_nutrient_values ADD PRIMARY KEY (dataset_version, "Food code");
+UPDATE fndds_nutrient_values
+SET description = to_tsvector('english', "Main food description")
+WHERE description IS NULL;
+CREATE INDEX IF NOT EXISTS idx_gin_description ON fndds_nutrient_values USING gin (description);
+CREATE INDEX IF NOT EXISTS idx_trgm_description ON fndds_nutrient_values USING gin ("Main food description" gin_trgm_ops);
+
+CREATE TABLE IF NOT EXISTS fndds_portions_weights (
+    dataset_version       TEXT NOT NULL,
+    "Food code"           numeric NOT NULL,
+    "Seq num"             numeric NOT NULL,
+    "Portion description" varchar NOT NULL,
+    "Portion weight (g)"  numeric NOT NULL,
+    PRIMARY KEY (dataset_version, "Food code", "Seq num")
+);
+ALTER TABLE fndds_portions_weights ADD COLUMN IF NOT EXISTS dataset_version TEXT NOT NULL DEFAULT '2021-2023';
+ALTER TABLE fndds_portions_weights ALTER COLUMN dataset_version DROP DEFAULT;
+DROP INDEX IF EXISTS idx_portions_food_code;
+CREATE INDEX idx_portions_food_code ON fndds_portions_weights (dataset_version, "Food code");
+
+-- Record the release an upgraded database was seeded with
+INSERT INTO fndds_datasets (version, source_file, food_count)
+SELECT '2021-2023', '2021-2023.xlsx', count(*) FROM fndds_nutrient_values
+WHERE dataset_version = '2021-2023'
+HAVING count(*) > 0
+ON CONFLICT (version) DO NOTHING;
+
+UPDATE fndds_datasets SET active = true
+WHERE version = '2021-2023'
+  AND NOT EXISTS (SELECT 1 FROM fndds_datasets WHERE active);
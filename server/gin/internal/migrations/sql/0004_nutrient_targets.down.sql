@@ -0,0 +1 @@
+DROP TABLE IF EXISTS nutrient_targets;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS nutrient_targets (
+    user_id     UUID PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    ckd_stage   INTEGER CHECK (ckd_stage BETWEEN 0 AND 5),
+    potassium   DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (potassium >= 0),
+    phosphorus  DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (phosphorus >= 0),
+    protein     DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (protein >= 0),
+    calories    DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (calories >= 0),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
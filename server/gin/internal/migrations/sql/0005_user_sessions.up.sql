@@ -0,0 +1,8 @@
+-- One JWT per user, as the old setup scripts created it. Databases made by
+-- those scripts already have the table
+CREATE TABLE IF NOT EXISTS user_sessions (
+    user_id    UUID PRIMARY KEY,
+    jwt_token  TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
@@ -0,0 +1,9 @@
+-- Back to the table of 0005, the refresh token sessions can't be kept
+DROP TABLE IF EXISTS user_sessions;
+
+CREATE TABLE user_sessions (
+    user_id    UUID PRIMARY KEY,
+    jwt_token  TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
@@ -0,0 +1,27 @@
+-- One row per login. The refresh token is stored hashed and replaced on
+-- every refresh, the previous hash is kept to spot a stolen token being
+-- replayed after rotation
+
+-- A stored JWT can't be refreshed, those users log in again
+DELETE FROM user_sessions;
+
+ALTER TABLE user_sessions DROP CONSTRAINT user_sessions_pkey;
+ALTER TABLE user_sessions DROP CONSTRAINT fk_user;
+ALTER TABLE user_sessions DROP COLUMN jwt_token;
+
+ALTER TABLE user_sessions
+    ADD COLUMN session_id UUID PRIMARY KEY,
+    ALTER COLUMN user_id SET NOT NULL,
+    ADD CONSTRAINT user_sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    ADD COLUMN refresh_token_hash TEXT NOT NULL UNIQUE,
+    ADD COLUMN previous_token_hash TEXT,
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN created_at SET NOT NULL,
+    ADD COLUMN rotated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN expires_at TIMESTAMPTZ NOT NULL,
+    ADD COLUMN revoked_at TIMESTAMPTZ;
+
+CREATE INDEX idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX idx_user_sessions_previous_token_hash ON user_sessions(previous_token_hash);
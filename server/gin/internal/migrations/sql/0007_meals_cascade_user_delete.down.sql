@@ -0,0 +1,3 @@
+ALTER TABLE meals DROP CONSTRAINT IF EXISTS meals_user_id_fkey;
+ALTER TABLE meals ADD CONSTRAINT meals_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(user_id);
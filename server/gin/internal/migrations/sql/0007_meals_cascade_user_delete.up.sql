@@ -0,0 +1,5 @@
+-- Deleting a user removes their meals along with their sessions and targets,
+-- all in the one DELETE statement
+ALTER TABLE meals DROP CONSTRAINT IF EXISTS meals_user_id_fkey;
+ALTER TABLE meals ADD CONSTRAINT meals_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE;
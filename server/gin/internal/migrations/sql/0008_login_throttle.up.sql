@@ -0,0 +1,10 @@
+-- Failed logins per username ("user:<name>") and per client IP ("ip:<addr>").
+-- Kept in postgres so lockouts survive restarts and apply across replicas
+CREATE TABLE login_throttle (
+    key             TEXT PRIMARY KEY,
+    failures        INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_login_throttle_last_failure_at ON login_throttle(last_failure_at);
@@ -0,0 +1,19 @@
+-- Optional TOTP second factor. A secret with no enabled_at is an enrollment
+-- waiting for its first code. last_used_step stops a code being replayed
+CREATE TABLE user_totp (
+    user_id        UUID PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    secret         TEXT NOT NULL,
+    enabled_at     TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One-time recovery codes, bcrypt hashed
+CREATE TABLE totp_recovery_codes (
+    id        SERIAL PRIMARY KEY,
+    user_id   UUID NOT NULL REFERENCES user_totp(user_id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_totp_recovery_codes_user_id ON totp_recovery_codes(user_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS password_resets;
+DROP INDEX IF EXISTS idx_users_email;
+ALTER TABLE users DROP COLUMN IF EXISTS email;
@@ -0,0 +1,15 @@
+-- Optional email to send password reset links to, unique regardless of case
+ALTER TABLE users ADD COLUMN email TEXT;
+
+CREATE UNIQUE INDEX idx_users_email ON users(lower(email));
+
+-- Single use password reset tokens, only their SHA-256 hash is stored
+CREATE TABLE password_resets (
+    token_hash TEXT PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_password_resets_user_id ON password_resets(user_id);
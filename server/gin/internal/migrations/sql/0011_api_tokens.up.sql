@@ -0,0 +1,15 @@
+-- Personal access tokens for scripts, only the SHA-256 hash of the token is
+-- stored. Scopes limit which API routes a token may call
+CREATE TABLE api_tokens (
+    token_id     UUID PRIMARY KEY,
+    user_id      UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    name         VARCHAR(100) NOT NULL,
+    token_hash   TEXT NOT NULL UNIQUE,
+    scopes       TEXT[] NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_tokens_user_id ON api_tokens(user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS patient_links;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
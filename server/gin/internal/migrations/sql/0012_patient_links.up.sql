@@ -0,0 +1,23 @@
+-- Patients are the default, clinicians read linked patients' data and admins
+-- manage roles
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'patient'
+    CHECK (role IN ('patient', 'clinician', 'admin'));
+
+-- A patient's invitation to another account. The link gives access once the
+-- invited member accepts it, and ends when either side revokes it
+CREATE TABLE patient_links (
+    link_id     UUID PRIMARY KEY,
+    patient_id  UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    member_id   UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    kind        TEXT NOT NULL CONSTRAINT patient_links_kind_check CHECK (kind IN ('clinician')),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    accepted_at TIMESTAMPTZ,
+    revoked_at  TIMESTAMPTZ,
+    CHECK (patient_id <> member_id)
+);
+
+-- One live link per patient, member and kind
+CREATE UNIQUE INDEX idx_patient_links_live ON patient_links(patient_id, member_id, kind)
+    WHERE revoked_at IS NULL;
+CREATE INDEX idx_patient_links_member_id ON patient_links(member_id)
+    WHERE revoked_at IS NULL;
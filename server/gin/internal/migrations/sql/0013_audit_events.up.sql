@@ -0,0 +1,15 @@
+-- Who accessed or changed whose health data. Users are not foreign keys so
+-- events outlive the accounts they mention
+CREATE TABLE audit_events (
+    event_id    BIGSERIAL PRIMARY KEY,
+    actor_id    UUID,
+    subject_id  UUID,
+    action      TEXT NOT NULL,
+    resource_id TEXT,
+    ip          TEXT,
+    user_agent  TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_audit_events_subject_id ON audit_events(subject_id, created_at);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE meals DROP COLUMN IF EXISTS entered_by;
+DELETE FROM patient_links WHERE kind = 'caregiver';
+ALTER TABLE patient_links DROP CONSTRAINT patient_links_kind_check;
+ALTER TABLE patient_links ADD CONSTRAINT patient_links_kind_check
+    CHECK (kind IN ('clinician'));
@@ -0,0 +1,8 @@
+-- Caregivers are linked like clinicians and log meals for the patient
+ALTER TABLE patient_links DROP CONSTRAINT patient_links_kind_check;
+ALTER TABLE patient_links ADD CONSTRAINT patient_links_kind_check
+    CHECK (kind IN ('clinician', 'caregiver'));
+
+-- Who entered a meal, the patient or a caregiver logging it for them
+ALTER TABLE meals ADD COLUMN entered_by UUID REFERENCES users(user_id) ON DELETE SET NULL;
+UPDATE meals SET entered_by = user_id;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_audit_events_action;
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+DROP TRIGGER IF EXISTS audit_events_no_change ON audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
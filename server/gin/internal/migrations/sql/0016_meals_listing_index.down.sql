@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_meals_user_type_time;
@@ -0,0 +1,3 @@
+-- Meal listings page newest first by (time, id) within a user's favorites or
+-- history
+CREATE INDEX idx_meals_user_type_time ON meals(user_id, meal_type, time DESC, id DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE meals DROP CONSTRAINT IF EXISTS meals_slot_history_only;
+ALTER TABLE meals DROP COLUMN IF EXISTS slot;
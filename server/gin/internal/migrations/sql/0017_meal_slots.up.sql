@@ -0,0 +1,13 @@
+-- The meal of the day a logged meal was, kept apart from meal_type which
+-- only tells favorites from history
+ALTER TABLE meals ADD COLUMN slot TEXT
+    CHECK (slot IN ('breakfast', 'lunch', 'dinner', 'snack'));
+
+ALTER TABLE meals ADD CONSTRAINT meals_slot_history_only
+    CHECK (slot IS NULL OR meal_type = 'history');
+
+-- Logged meals named after their slot already say which one they were
+UPDATE meals
+SET slot = CASE lower(btrim(meal_name)) WHEN 'snacks' THEN 'snack' ELSE lower(btrim(meal_name)) END
+WHERE meal_type = 'history'
+  AND lower(btrim(meal_name)) IN ('breakfast', 'lunch', 'dinner', 'snack', 'snacks');
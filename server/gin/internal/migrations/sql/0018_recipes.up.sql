@@ -0,0 +1,18 @@
+-- Recipes are cooked once and eaten over several servings. Ingredients are
+-- the raw weights that went in, totals are their sums for the whole recipe
+CREATE TABLE recipes (
+    id            SERIAL PRIMARY KEY,
+    user_id       UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    name          TEXT NOT NULL,
+    -- How many servings the recipe makes
+    servings      DOUBLE PRECISION NOT NULL CHECK (servings > 0),
+    -- What the cooked dish weighs, NULL if it was not weighed
+    cooked_grams  DOUBLE PRECISION CHECK (cooked_grams > 0),
+    ingredients   JSONB NOT NULL,
+    totals        JSONB NOT NULL,
+    fndds_version TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_recipes_user_id ON recipes(user_id);
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+/*
+ * Request bodies for the account settings endpoints
+ */
+
+type PasswordChange struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// Validate Checks both passwords are given and the new one is different
+func (p *PasswordChange) Validate() error {
+	if p.CurrentPassword == "" {
+		return errors.New("no current password given")
+	}
+	if p.NewPassword == "" {
+		return errors.New("no new password given")
+	}
+	if p.NewPassword == p.CurrentPassword {
+		return errors.New("new password must be different from the current password")
+	}
+	return nil
+}
+
+// AccountUpdate changes the names, and the email when it is given. An empty
+// email removes it
+type AccountUpdate struct {
+	FirstName string  `json:"firstName"`
+	LastName  string  `json:"lastName"`
+	Email     *string `json:"email"`
+}
+
+// Validate Trims the names and email and checks they fit the users table
+func (a *AccountUpdate) Validate() error {
+	a.FirstName = strings.TrimSpace(a.FirstName)
+	a.LastName = strings.TrimSpace(a.LastName)
+	if a.Email != nil {
+		email := strings.TrimSpace(*a.Email)
+		a.Email = &email
+		if email != "" {
+			if err := ValidateEmail(email); err != nil {
+				return err
+			}
+		}
+	}
+	if a.FirstName == "" || a.LastName == "" {
+		return errors.New("first and last name are required")
+	}
+	if len(a.FirstName) > 100 || len(a.LastName) > 100 {
+		return errors.New("names must be at most 100 characters")
+	}
+	return nil
+}
+
+type AccountDeletion struct {
+	Password string `json:"password"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+ * APIToken is a personal access token a user creates for scripts and
+ * integrations. The token itself is only shown once, when it is created
+ */
+
+// Scopes a personal access token can be granted
+const (
+	ScopeMealsRead   = "meals:read"
+	ScopeMealsWrite  = "meals:write"
+	ScopeHistoryRead = "history:read"
+)
+
+// APITokenScopes are every scope, in the order they are listed to users
+var APITokenScopes = []string{ScopeMealsRead, ScopeMealsWrite, ScopeHistoryRead}
+
+// MaxAPITokenDays is the longest a personal access token can be valid for
+const MaxAPITokenDays = 365
+
+type APIToken struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+}
+
+// APITokenRequest is the body of the create token endpoint. ExpiresInDays 0
+// makes a token that doesn't expire
+type APITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"`
+}
+
+// Validate Trims the name and checks the scopes and expiry
+func (r *APITokenRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 100 {
+		return errors.New("name is required and must be at most 100 characters")
+	}
+	if len(r.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	seen := map[string]bool{}
+	scopes := r.Scopes[:0]
+	for _, scope := range r.Scopes {
+		if !isAPITokenScope(scope) {
+			return fmt.Errorf("unknown scope %q, expected one of %s", scope, strings.Join(APITokenScopes, ", "))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	r.Scopes = scopes
+	if r.ExpiresInDays < 0 || r.ExpiresInDays > MaxAPITokenDays {
+		return fmt.Errorf("expiresInDays must be between 0 and %d", MaxAPITokenDays)
+	}
+	return nil
+}
+
+func isAPITokenScope(scope string) bool {
+	for _, s := range APITokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+ * AuditEvent records that an actor read or changed data of a subject user
+ */
+
+// Audited actions
+const (
+	AuditLinkInvite         = "link.invite"
+	AuditLinkAccept         = "link.accept"
+	AuditLinkRevoke         = "link.revoke"
+	AuditPatientMealsRead   = "patient.meals.read"
+	AuditPatientHistoryRead = "patient.nutrient_history.read"
+	AuditUserRoleChange     = "user.role.change"
+	AuditCaregiverRequest   = "caregiver.request"
+
+	AuditMealsRead           = "meals.read"
+	AuditMealCreate          = "meal.create"
+	AuditMealUpdate          = "meal.update"
+	AuditMealDelete          = "meal.delete"
+	AuditMealImport          = "meal.import"
+	AuditNutrientHistoryRead = "nutrient_history.read"
+	AuditRecipeCreate        = "recipe.create"
+	AuditRecipeUpdate        = "recipe.update"
+	AuditRecipeDelete        = "recipe.delete"
+
+	AuditUserCreate     = "user.create"
+	AuditUserUpdate     = "user.update"
+	AuditUserDelete     = "user.delete"
+	AuditUserExport     = "user.export"
+	AuditPasswordChange = "user.password.change"
+	AuditPasswordReset  = "user.password.reset"
+	AuditTOTPEnable     = "user.totp.enable"
+	AuditTOTPDisable    = "user.totp.disable"
+	AuditAPITokenCreate = "user.api_token.create"
+	AuditAPITokenRevoke = "user.api_token.revoke"
+	AuditLogin          = "auth.login"
+	AuditLoginFailed    = "auth.login.failed"
+	AuditLogout         = "auth.logout"
+
+	AuditLogRead = "audit.read"
+)
+
+type AuditEvent struct {
+	ID         int64      `json:"id"`
+	ActorID    *uuid.UUID `json:"actorId"`
+	SubjectID  *uuid.UUID `json:"subjectId"`
+	Action     string     `json:"action"`
+	ResourceID string     `json:"resourceId"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"userAgent"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// AuditFilter narrows a query of the audit log, zero fields match anything.
+// Events come newest first, starting below Before when it is set
+type AuditFilter struct {
+	ActorID   *uuid.UUID
+	SubjectID *uuid.UUID
+	Action    string
+	From      time.Time
+	To        time.Time
+	Before    int64
+	Limit     int
+}
@@ -7,6 +7,38 @@ package models
 import "github.com/golang-jwt/jwt/v5"
 
 type Claims struct {
-	UserID string `json:"userid"`
+	UserID    string `json:"userid"`
+	SessionID string `json:"sid,omitempty"`
+	// TokenID and Scopes are set for requests made with a personal access
+	// token instead of a session, they are never part of a JWT
+	TokenID string   `json:"-"`
+	Scopes  []string `json:"-"`
+	// ActorID is set when a caregiver acts for a patient, UserID is then the
+	// patient and ActorID the caregiver
+	ActorID string `json:"-"`
 	jwt.RegisteredClaims
 }
+
+// Actor Returns the user actually making the request
+func (c *Claims) Actor() string {
+	if c.ActorID != "" {
+		return c.ActorID
+	}
+	return c.UserID
+}
+
+// IsAPIToken Reports whether the request was made with a personal access
+// token
+func (c *Claims) IsAPIToken() bool {
+	return c.TokenID != ""
+}
+
+// HasScope Reports whether a personal access token was granted scope
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
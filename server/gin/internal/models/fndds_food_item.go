@@ -13,4 +13,34 @@ type FnddsFoodItem struct {
 	Calories    float64 `json:"Calories"`
 	Protein     float64 `json:"Protein (g)"`
 	Carbs       float64 `json:"Carbohydrate (g)"`
+	// DatasetVersion is the FNDDS release the values come from, e.g.
+	// "2021-2023"
+	DatasetVersion string `json:"Dataset version,omitempty"`
+}
+
+// FnddsPortion is a household measure for a food item from the FNDDS portions
+// and weights dataset, e.g. "1 cup" of a food weighs Grams
+type FnddsPortion struct {
+	FoodCode    int     `json:"foodCode"`
+	SeqNum      int     `json:"seqNum"`
+	Description string  `json:"description"`
+	Grams       float64 `json:"grams"`
+}
+
+// ForGrams Returns the ingredient for the given weight, FNDDS values are per
+// 100 g
+func (item *FnddsFoodItem) ForGrams(grams float64) Ingredient {
+	factor := grams / 100
+	return Ingredient{
+		Name:         item.Description,
+		FoodCode:     item.FoodCode,
+		Grams:        grams,
+		Calories:     item.Calories * factor,
+		Protein:      item.Protein * factor,
+		Carbs:        item.Carbs * factor,
+		Phosphorus:   item.Phosphorus * factor,
+		Potassium:    item.Potassium * factor,
+		Verified:     true,
+		FnddsVersion: item.DatasetVersion,
+	}
 }
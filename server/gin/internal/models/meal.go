@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 /*
  * Meal is the model for meals that a user records in kayphos, a Meal can be
@@ -8,20 +13,73 @@ import "time"
  */
 
 type Ingredient struct {
-	Name       string  `json:"name"`
+	Name     string `json:"name"`
+	FoodCode int    `json:"foodCode,omitempty"`
+	// Portion and Quantity are an alternative to Grams for ingredients with a
+	// food code, e.g. 2 of "1 cup, sliced"
+	Portion    string  `json:"portion,omitempty"`
+	Quantity   float64 `json:"quantity,omitempty"`
 	Grams      float64 `json:"grams"`
 	Calories   float64 `json:"calories"`
 	Protein    float64 `json:"protein"`
 	Carbs      float64 `json:"carbs"`
 	Phosphorus float64 `json:"phosphorus"`
 	Potassium  float64 `json:"potassium"`
+	// Verified is set when the nutrients were computed by the server from
+	// FoodCode rather than sent by the client
+	Verified bool `json:"verified,omitempty"`
+	// FnddsVersion is the FNDDS release verified nutrients were computed from
+	FnddsVersion string `json:"fnddsVersion,omitempty"`
 }
 
 type MealGroup struct {
-	MealName    string       `json:"mealName"`
-	Time        time.Time    `json:"time"`
-	MealType    string       `json:"mealType"`
+	ID       int       `json:"id"`
+	MealName string    `json:"mealName"`
+	Time     time.Time `json:"time"`
+	MealType string    `json:"mealType"`
+	// Slot is the meal of the day a logged meal was, see MealSlots. Favorites
+	// have none
+	Slot        string       `json:"slot,omitempty"`
 	Ingredients []Ingredient `json:"ingredients"`
+	// FnddsVersion is the FNDDS release the meal totals were computed from,
+	// empty if no ingredient was verified
+	FnddsVersion string `json:"fnddsVersion,omitempty"`
+	// EnteredBy is the user who logged the meal, a caregiver or the user
+	EnteredBy *uuid.UUID `json:"enteredBy,omitempty"`
+}
+
+// Meal slots, the meal of the day a logged meal was eaten as
+const (
+	MealSlotBreakfast = "breakfast"
+	MealSlotLunch     = "lunch"
+	MealSlotDinner    = "dinner"
+	MealSlotSnack     = "snack"
+)
+
+// MealSlots are the slots in the order of a day
+var MealSlots = []string{MealSlotBreakfast, MealSlotLunch, MealSlotDinner, MealSlotSnack}
+
+// ValidMealSlot Reports whether slot is one of MealSlots, or empty for none
+func ValidMealSlot(slot string) bool {
+	if slot == "" {
+		return true
+	}
+	for _, s := range MealSlots {
+		if slot == s {
+			return true
+		}
+	}
+	return false
+}
+
+// MealSlotFromName Returns the slot a meal name such as "Dinner" or "Snacks"
+// stands for, or "" when it names none
+func MealSlotFromName(name string) string {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), "s")
+	if name != "" && ValidMealSlot(name) {
+		return name
+	}
+	return ""
 }
 
 type MealEntry struct {
@@ -35,3 +93,60 @@ type MealEntry struct {
 	Phosphorus float64   `json:"phosphorus"`
 	Potassium  float64   `json:"potassium"`
 }
+
+// SumIngredients Sums the nutrients of the given ingredients into the totals
+// map stored alongside each meal
+func SumIngredients(ingredients []Ingredient) map[string]float64 {
+	var totalK, totalP, totalCals, totalPro, totalCarbs float64
+	for _, ing := range ingredients {
+		totalK += ing.Potassium
+		totalP += ing.Phosphorus
+		totalCals += ing.Calories
+		totalPro += ing.Protein
+		totalCarbs += ing.Carbs
+	}
+
+	return map[string]float64{
+		"potassium":  totalK,
+		"phosphorus": totalP,
+		"calories":   totalCals,
+		"protein":    totalPro,
+		"carbs":      totalCarbs,
+	}
+}
+
+// FnddsVersion Returns the FNDDS release the verified ingredients were computed
+// from, or nil if none were verified
+func FnddsVersion(ingredients []Ingredient) *string {
+	for _, ing := range ingredients {
+		if ing.Verified && ing.FnddsVersion != "" {
+			version := ing.FnddsVersion
+			return &version
+		}
+	}
+	return nil
+}
+
+// MealFilter narrows a listing of a user's meals, zero fields match anything.
+// Meals come newest first, starting after the meal at AfterTime and AfterID
+// when those are set
+type MealFilter struct {
+	MealType   string
+	Slot       string
+	From       time.Time
+	To         time.Time
+	Query      string
+	Ingredient string
+	FoodCode   int
+	AfterTime  time.Time
+	AfterID    int
+	Limit      int
+}
+
+// MealPage is one page of a meal listing. NextCursor fetches the next page,
+// it is empty on the last one
+type MealPage struct {
+	Meals      []MealGroup `json:"meals"`
+	Total      int         `json:"total"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+/*
+ * MealImport is a CSV of meals logged in another app, previewed before it is
+ * added to the user's history
+ */
+
+// Import formats, told apart by their header
+const (
+	ImportFormatCSV          = "csv"
+	ImportFormatMyFitnessPal = "myfitnesspal"
+)
+
+// ImportRow is one food of an import file
+type ImportRow struct {
+	// Line is the row's line in the file, the header is line 1
+	Line     int       `json:"line"`
+	Time     time.Time `json:"time"`
+	MealName string    `json:"mealName"`
+	Food     string    `json:"food"`
+	Grams    float64   `json:"grams"`
+	// FoodCode and Description are the FNDDS food the row matched
+	FoodCode    int    `json:"foodCode,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Error is why the row can't be imported
+	Error string `json:"error,omitempty"`
+}
+
+// ImportPreview is what an import adds to the history: the meals built from
+// the matched rows and their totals, and the rows that are left out
+type ImportPreview struct {
+	Format    string             `json:"format"`
+	Meals     []MealGroup        `json:"meals"`
+	Matched   []ImportRow        `json:"matched"`
+	Unmatched []ImportRow        `json:"unmatched"`
+	Totals    map[string]float64 `json:"totals"`
+	Committed bool               `json:"committed"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+/*
+ * NutrientTargets is the model for the daily nutrient limits of a user,
+ * usually set by a dietitian from the user's CKD stage. A limit of 0 means no
+ * limit is set for that nutrient
+ */
+
+type NutrientTargets struct {
+	CKDStage   int       `json:"ckdStage"`
+	Potassium  float64   `json:"potassium"`
+	Phosphorus float64   `json:"phosphorus"`
+	Protein    float64   `json:"protein"`
+	Calories   float64   `json:"calories"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// NutrientUsage is how much of a single daily limit has been consumed
+type NutrientUsage struct {
+	Consumed  float64 `json:"consumed"`
+	Limit     float64 `json:"limit"`
+	Percent   float64 `json:"percent"`
+	OverLimit bool    `json:"overLimit"`
+}
+
+// Validate Checks targets for an out of range CKD stage or negative limits
+func (t *NutrientTargets) Validate() error {
+	if t.CKDStage < 0 || t.CKDStage > 5 {
+		return errors.New("ckd stage must be between 0 and 5")
+	}
+	if t.Potassium < 0 || t.Phosphorus < 0 || t.Protein < 0 || t.Calories < 0 {
+		return errors.New("nutrient limits cannot be negative")
+	}
+	return nil
+}
+
+// Limits Returns the set limits keyed the same way as meal totals
+func (t *NutrientTargets) Limits() map[string]float64 {
+	limits := map[string]float64{}
+	if t.Potassium > 0 {
+		limits["potassium"] = t.Potassium
+	}
+	if t.Phosphorus > 0 {
+		limits["phosphorus"] = t.Phosphorus
+	}
+	if t.Protein > 0 {
+		limits["protein"] = t.Protein
+	}
+	if t.Calories > 0 {
+		limits["calories"] = t.Calories
+	}
+	return limits
+}
+
+// Usage Computes how much of each set limit the given totals consume, and
+// whether any limit is exceeded
+func (t *NutrientTargets) Usage(totals map[string]float64) (map[string]NutrientUsage, bool) {
+	usage := map[string]NutrientUsage{}
+	over := false
+	for nutrient, limit := range t.Limits() {
+		consumed := totals[nutrient]
+		u := NutrientUsage{
+			Consumed:  consumed,
+			Limit:     limit,
+			Percent:   math.Round(consumed/limit*1000) / 10,
+			OverLimit: consumed > limit,
+		}
+		over = over || u.OverLimit
+		usage[nutrient] = u
+	}
+	return usage, over
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+ * Request bodies for the forgotten password endpoints
+ */
+
+type ForgotPassword struct {
+	Email string `json:"email"`
+}
+
+// Validate Trims the email and checks it is an address
+func (f *ForgotPassword) Validate() error {
+	f.Email = strings.TrimSpace(f.Email)
+	return ValidateEmail(f.Email)
+}
+
+type PasswordReset struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
+
+// Validate Checks the token and new password are given, and that the
+// password is short enough to hash
+func (p *PasswordReset) Validate() error {
+	if p.Token == "" {
+		return errors.New("no reset token given")
+	}
+	if p.NewPassword == "" {
+		return errors.New("no new password given")
+	}
+	if len(p.NewPassword) > MaxPasswordBytes {
+		return fmt.Errorf("new password cannot be longer than %d bytes", MaxPasswordBytes)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+ * PatientLink gives another account access to a patient's data. The patient
+ * invites the member, who has access from when they accept until either side
+ * revokes the link
+ */
+
+// Kinds of patient link
+const (
+	LinkClinician = "clinician"
+	LinkCaregiver = "caregiver"
+)
+
+type PatientLink struct {
+	ID         uuid.UUID  `json:"id"`
+	PatientID  uuid.UUID  `json:"-"`
+	MemberID   uuid.UUID  `json:"-"`
+	Kind       string     `json:"kind"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	AcceptedAt *time.Time `json:"acceptedAt"`
+	// User is the other side of the link, the member when the patient lists
+	// links and the patient when the member does
+	User LinkedUser `json:"user"`
+}
+
+// LinkedUser is what either side of a link sees of the other
+type LinkedUser struct {
+	UserID    uuid.UUID `json:"userId"`
+	UserName  string    `json:"username"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+}
+
+// LinkInvite is the body of an invitation, the username or email of the
+// account to link
+type LinkInvite struct {
+	User string `json:"user"`
+}
+
+// RoleChange is the body of the admin endpoint that sets a user's role
+type RoleChange struct {
+	Role string `json:"role"`
+}
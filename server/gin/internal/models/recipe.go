@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+/*
+ * Recipe is the model for a dish a user cooks once and eats over several
+ * servings. Its ingredients are the raw weights that went in, a logged
+ * serving is the matching share of each of them
+ */
+
+type Recipe struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Servings is how many servings the recipe makes
+	Servings float64 `json:"servings"`
+	// CookedGrams is what the cooked dish weighs, 0 if it was not weighed.
+	// Cooking changes the weight, so grams eaten are a share of this
+	CookedGrams float64      `json:"cookedGrams,omitempty"`
+	Ingredients []Ingredient `json:"ingredients"`
+	// Totals are the nutrients of the whole recipe, PerServing of a serving
+	Totals       map[string]float64 `json:"totals"`
+	PerServing   map[string]float64 `json:"perServing"`
+	FnddsVersion string             `json:"fnddsVersion,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt"`
+	UpdatedAt    time.Time          `json:"updatedAt"`
+}
+
+// Validate Checks a recipe has a name, ingredients and a positive yield
+func (r *Recipe) Validate() error {
+	if r.Name == "" {
+		return errors.New("recipe needs a name")
+	}
+	if len(r.Ingredients) == 0 {
+		return errors.New("recipe needs at least one ingredient")
+	}
+	if r.Servings <= 0 {
+		return errors.New("servings must be positive")
+	}
+	if r.CookedGrams < 0 {
+		return errors.New("cooked weight cannot be negative")
+	}
+	return nil
+}
+
+// SetTotals Sums the ingredients into Totals and PerServing
+func (r *Recipe) SetTotals() {
+	r.Totals = SumIngredients(r.Ingredients)
+	r.PerServing = make(map[string]float64, len(r.Totals))
+	for k, v := range r.Totals {
+		r.PerServing[k] = v / r.Servings
+	}
+	r.FnddsVersion = ""
+	if version := FnddsVersion(r.Ingredients); version != nil {
+		r.FnddsVersion = *version
+	}
+}
+
+// Share Returns the share of the recipe in servings or grams of the cooked
+// dish, exactly one of which is set
+func (r *Recipe) Share(servings, grams float64) (float64, error) {
+	switch {
+	case servings < 0 || grams < 0:
+		return 0, errors.New("servings and grams cannot be negative")
+	case (servings > 0) == (grams > 0):
+		return 0, errors.New("give either servings or grams eaten")
+	case servings > 0:
+		return servings / r.Servings, nil
+	case r.CookedGrams <= 0:
+		return 0, errors.New("recipe has no cooked weight, log it by servings")
+	default:
+		return grams / r.CookedGrams, nil
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+ * Session is a login of a user, kept alive by a rotating refresh token and
+ * ended by logout or revocation
+ */
+
+type Session struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"-"`
+	UserAgent string     `json:"userAgent"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RotatedAt time.Time  `json:"lastUsedAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// RefreshRequest is the body of the token refresh of API clients, which keep
+// the refresh token themselves instead of in a cookie
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
@@ -0,0 +1,27 @@
+package models
+
+/*
+ * TOTP is the second factor of a user, Enabled once the first code from the
+ * authenticator app was verified
+ */
+
+type TOTP struct {
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+}
+
+type RecoveryCode struct {
+	ID   int
+	Hash string
+}
+
+// TOTPCode is the body of the TOTP verify, disable and login endpoints. Either
+// a code from the app or a recovery code is given. API clients send back the
+// MFAToken of the first login step, browsers have it in a cookie
+type TOTPCode struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+	Password     string `json:"password"`
+	MFAToken     string `json:"mfaToken"`
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"errors"
 	"log"
+	"net/mail"
+	"strings"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -20,6 +22,20 @@ type User struct {
 	UserID         uuid.UUID `json:"userid"`
 	HashedPassword string    `json:"hashedpassword"`
 	InputPassword  string    `json:"inputpassword"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+}
+
+// Roles a user can have, every new account is a patient
+const (
+	RolePatient   = "patient"
+	RoleClinician = "clinician"
+	RoleAdmin     = "admin"
+)
+
+// ValidRole Reports whether role is one of the roles
+func ValidRole(role string) bool {
+	return role == RolePatient || role == RoleClinician || role == RoleAdmin
 }
 
 // SetUserID Set user id for a newly created User
@@ -27,11 +43,22 @@ func (user *User) SetUserID() {
 	user.UserID = uuid.New()
 }
 
+// dummyHash is compared against when there is no stored hash, so a login for
+// an unknown username takes as long as one with a wrong password
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("kayphos"), bcrypt.DefaultCost)
+
 // VerifyPassword Verifies input password against hashed password
 func (user *User) VerifyPassword() bool {
+	if user.HashedPassword == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(user.InputPassword))
+		return false
+	}
 	return bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(user.InputPassword)) == nil
 }
 
+// MaxPasswordBytes is the longest password bcrypt hashes
+const MaxPasswordBytes = 72
+
 // SetHashedPassword Sets hashed password for a newly created User given a password
 func (user *User) SetHashedPassword() error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.InputPassword), bcrypt.DefaultCost)
@@ -64,3 +91,21 @@ func (user *User) CheckPasswordAndUsername() error {
 	}
 	return nil
 }
+
+// CheckEmail Trims the optional email and checks it is a plain address
+func (user *User) CheckEmail() error {
+	user.Email = strings.TrimSpace(user.Email)
+	if user.Email == "" {
+		return nil
+	}
+	return ValidateEmail(user.Email)
+}
+
+// ValidateEmail Checks email is a bare address like name@example.com
+func ValidateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > 254 {
+		return errors.New("invalid email address")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- CreateAPIToken() + ListAPITokens(): tokens are listed for their owner
+//- UseAPIToken(): returns the scopes, stops working once revoked or expired
+//- RevokeAPIToken(): only the owner can revoke a token
+
+func TestAPITokens(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+	other := createRandomTestUser(t, pool)
+
+	token := &models.APIToken{ID: uuid.New(), UserID: user.UserID, Name: "script", Scopes: []string{models.ScopeMealsRead}}
+	hash := "hash_" + uuid.NewString()
+	assert.NoError(t, CreateAPIToken(pool, token, hash))
+
+	tokens, err := ListAPITokens(pool, user.UserID)
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 1)
+	assert.Equal(t, token.ID, tokens[0].ID)
+
+	used, err := UseAPIToken(pool, hash)
+	assert.NoError(t, err)
+	assert.Equal(t, user.UserID, used.UserID)
+	assert.Equal(t, []string{models.ScopeMealsRead}, used.Scopes)
+
+	revoked, err := RevokeAPIToken(pool, other.UserID, token.ID)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = RevokeAPIToken(pool, user.UserID, token.ID)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	used, err = UseAPIToken(pool, hash)
+	assert.NoError(t, err)
+	assert.Nil(t, used)
+
+	expiresAt := time.Now().Add(-time.Minute)
+	expired := &models.APIToken{ID: uuid.New(), UserID: user.UserID, Name: "old", Scopes: []string{models.ScopeMealsRead}, ExpiresAt: &expiresAt}
+	expiredHash := "hash_" + uuid.NewString()
+	assert.NoError(t, CreateAPIToken(pool, expired, expiredHash))
+	used, err = UseAPIToken(pool, expiredHash)
+	assert.NoError(t, err)
+	assert.Nil(t, used)
+}
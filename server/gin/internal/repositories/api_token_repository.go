@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+)
+
+/*
+ * API token repository interacts with api_tokens table in postgres
+ */
+
+// CreateAPIToken stores a new personal access token by the hash of its value
+func CreateAPIToken(db DBClient, t *models.APIToken, tokenHash string) error {
+	return db.QueryRow(context.Background(), `
+		INSERT INTO api_tokens (token_id, user_id, name, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at;
+	`, t.ID, t.UserID, t.Name, tokenHash, t.Scopes, t.ExpiresAt).Scan(&t.CreatedAt)
+}
+
+// ListAPITokens fetches the tokens of a user that are not revoked, newest first
+func ListAPITokens(db DBClient, userID uuid.UUID) ([]models.APIToken, error) {
+	rows, err := db.Query(context.Background(), `
+		SELECT token_id, name, scopes, created_at, last_used_at, expires_at
+		FROM api_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC;
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		t := models.APIToken{UserID: userID}
+		if err := rows.Scan(&t.ID, &t.Name, &t.Scopes, &t.CreatedAt, &t.LastUsedAt, &t.ExpiresAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// UseAPIToken looks up a live token by its hash and records that it was used.
+// Returns nil if the token is unknown, revoked or expired
+func UseAPIToken(db DBClient, tokenHash string) (*models.APIToken, error) {
+	var t models.APIToken
+	err := db.QueryRow(context.Background(), `
+		UPDATE api_tokens SET last_used_at = now()
+		WHERE token_hash = $1 AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > now())
+		RETURNING token_id, user_id, scopes;
+	`, tokenHash).Scan(&t.ID, &t.UserID, &t.Scopes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RevokeAPIToken revokes a token of a user, returns false if the user has no
+// such live token
+func RevokeAPIToken(db DBClient, userID, tokenID uuid.UUID) (bool, error) {
+	cmdTag, err := db.Exec(context.Background(), `
+		UPDATE api_tokens SET revoked_at = now()
+		WHERE token_id = $1 AND user_id = $2 AND revoked_at IS NULL;
+	`, tokenID, userID)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- ListAuditEvents(): filters by subject and action, pages with Before
+//- audit_events: rows can't be updated or deleted
+
+func TestAuditEvents(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	actor, subject := uuid.New(), uuid.New()
+	for _, action := range []string{models.AuditMealCreate, models.AuditMealUpdate, models.AuditMealCreate} {
+		assert.NoError(t, InsertAuditEvent(pool, &models.AuditEvent{ActorID: &actor, SubjectID: &subject, Action: action, IP: "127.0.0.1"}))
+	}
+
+	events, err := ListAuditEvents(pool, &models.AuditFilter{SubjectID: &subject, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Greater(t, events[0].ID, events[1].ID)
+	assert.Equal(t, "127.0.0.1", events[0].IP)
+
+	events, err = ListAuditEvents(pool, &models.AuditFilter{SubjectID: &subject, Action: models.AuditMealCreate, Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	older, err := ListAuditEvents(pool, &models.AuditFilter{SubjectID: &subject, Action: models.AuditMealCreate, Before: events[0].ID, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, older, 1)
+
+	events, err = ListAuditEvents(pool, &models.AuditFilter{SubjectID: &subject, From: time.Now().Add(time.Hour), Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = pool.Exec(context.Background(), `UPDATE audit_events SET action = 'x' WHERE subject_id = $1;`, subject)
+	assert.Error(t, err)
+	_, err = pool.Exec(context.Background(), `DELETE FROM audit_events WHERE subject_id = $1;`, subject)
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+)
+
+/*
+ * Audit repository interacts with audit_events table in postgres
+ */
+
+// InsertAuditEvent appends an event to the audit log
+func InsertAuditEvent(db DBClient, e *models.AuditEvent) error {
+	return db.QueryRow(context.Background(), `
+		INSERT INTO audit_events (actor_id, subject_id, action, resource_id, ip, user_agent)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING event_id, created_at;
+	`, e.ActorID, e.SubjectID, e.Action, e.ResourceID, e.IP, e.UserAgent).Scan(&e.ID, &e.CreatedAt)
+}
+
+// ListAuditEvents returns the events matching the filter, newest first
+func ListAuditEvents(db DBClient, f *models.AuditFilter) ([]models.AuditEvent, error) {
+	var from, to *time.Time
+	if !f.From.IsZero() {
+		from = &f.From
+	}
+	if !f.To.IsZero() {
+		to = &f.To
+	}
+
+	rows, err := db.Query(context.Background(), `
+		SELECT event_id, actor_id, subject_id, action, COALESCE(resource_id, ''),
+		       COALESCE(ip, ''), COALESCE(user_agent, ''), created_at
+		FROM audit_events
+		WHERE ($1::uuid IS NULL OR actor_id = $1)
+		  AND ($2::uuid IS NULL OR subject_id = $2)
+		  AND ($3 = '' OR action = $3)
+		  AND ($4::timestamptz IS NULL OR created_at >= $4)
+		  AND ($5::timestamptz IS NULL OR created_at < $5)
+		  AND ($6 = 0 OR event_id < $6)
+		ORDER BY event_id DESC
+		LIMIT $7;
+	`, f.ActorID, f.SubjectID, f.Action, from, to, f.Before, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var e models.AuditEvent
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.SubjectID, &e.Action, &e.ResourceID, &e.IP, &e.UserAgent, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
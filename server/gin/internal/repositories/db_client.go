@@ -17,6 +17,10 @@ type DBClient interface {
 
 type FnddsRepo interface {
 	FnddsQuery(db DBClient, ingredientName string) (*[]models.FnddsFoodItem, error)
+	FnddsSearch(db DBClient, ingredientName string, version string) (*[]models.FnddsFoodItem, error)
+	FnddsLookup(db DBClient, foodCodes []int) (map[int]models.FnddsFoodItem, error)
+	FnddsPortions(db DBClient, foodCode int) ([]models.FnddsPortion, error)
+	FnddsPortionsLookup(db DBClient, foodCodes []int) (map[int][]models.FnddsPortion, error)
 }
 
 type MockFnddsRepo struct {
@@ -27,3 +31,23 @@ func (m *MockFnddsRepo) FnddsQuery(db DBClient, ingredientName string) (*[]model
 	args := m.Called(db, ingredientName)
 	return args.Get(0).(*[]models.FnddsFoodItem), args.Error(1)
 }
+
+func (m *MockFnddsRepo) FnddsLookup(db DBClient, foodCodes []int) (map[int]models.FnddsFoodItem, error) {
+	args := m.Called(db, foodCodes)
+	return args.Get(0).(map[int]models.FnddsFoodItem), args.Error(1)
+}
+
+func (m *MockFnddsRepo) FnddsPortions(db DBClient, foodCode int) ([]models.FnddsPortion, error) {
+	args := m.Called(db, foodCode)
+	return args.Get(0).([]models.FnddsPortion), args.Error(1)
+}
+
+func (m *MockFnddsRepo) FnddsPortionsLookup(db DBClient, foodCodes []int) (map[int][]models.FnddsPortion, error) {
+	args := m.Called(db, foodCodes)
+	return args.Get(0).(map[int][]models.FnddsPortion), args.Error(1)
+}
+
+func (m *MockFnddsRepo) FnddsSearch(db DBClient, ingredientName string, version string) (*[]models.FnddsFoodItem, error) {
+	args := m.Called(db, ingredientName, version)
+	return args.Get(0).(*[]models.FnddsFoodItem), args.Error(1)
+}
@@ -39,21 +39,21 @@ func PrepareSQLStatements(config *pgxpool.Config) {
 			return err
 		}
 		// Prepare user insert query
-		_, err = conn.Prepare(ctx, "user_insert_query", "insert into users (first_name, last_name, user_name, user_id, hashed_password) values ($1, $2, $3, $4, $5)")
+		_, err = conn.Prepare(ctx, "user_insert_query", "insert into users (first_name, last_name, user_name, user_id, hashed_password, email) values ($1, $2, $3, $4, $5, nullif($6, ''))")
 		if err != nil {
 			log.Println("user insert error.")
 			log.Println(err)
 			return err
 		}
 		// Prepare user select by username query
-		_, err = conn.Prepare(ctx, "user_select_username_query", "select * from users where users.user_name = $1;")
+		_, err = conn.Prepare(ctx, "user_select_username_query", "select first_name, last_name, user_name, user_id, hashed_password, coalesce(email, ''), role from users where users.user_name = $1;")
 		if err != nil {
 			log.Println("select by username error.")
 			log.Println(err)
 			return err
 		}
 		// Prepare user select by userid query
-		_, err = conn.Prepare(ctx, "user_select_userid_query", "select * from users where users.user_id = $1;")
+		_, err = conn.Prepare(ctx, "user_select_userid_query", "select first_name, last_name, user_name, user_id, hashed_password, coalesce(email, ''), role from users where users.user_id = $1;")
 		if err != nil {
 			log.Println("select by userID error.")
 			log.Println(err)
@@ -0,0 +1,43 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/kimsh02/kay-phos/server/gin/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- FnddsSearch(): a query that fails while reading is an error rather than
+//  no matches, and the rows of every word order tried are closed
+
+func TestFnddsSearch_ReadError(t *testing.T) {
+	rows := &testutils.ResultRows{Failure: errors.New("connection reset")}
+	mockDB := new(testutils.MockDB)
+	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(rows, nil).Once()
+
+	items, err := repositories.Fndds{}.FnddsSearch(mockDB, "white rice", "")
+
+	assert.Error(t, err)
+	assert.Nil(t, items)
+	assert.True(t, rows.Closed)
+	// The other word order is not tried
+	mockDB.AssertNumberOfCalls(t, "Query", 1)
+}
+
+func TestFnddsSearch_NoMatches(t *testing.T) {
+	first, second := &testutils.ResultRows{}, &testutils.ResultRows{}
+	mockDB := new(testutils.MockDB)
+	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(first, nil).Once()
+	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(second, nil).Once()
+
+	items, err := repositories.Fndds{}.FnddsSearch(mockDB, "white rice", "")
+
+	assert.NoError(t, err)
+	assert.Nil(t, items)
+	assert.True(t, first.Closed)
+	assert.True(t, second.Closed)
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
 
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
@@ -10,21 +11,31 @@ import (
 
 type Fndds struct{}
 
-// FnddsQuery performs an websearch_to_tsquery for looser match on description
+// activeVersion selects the FNDDS release that searches default to
+const activeVersion = `(SELECT version FROM fndds_datasets WHERE active)`
+
+// FnddsQuery searches the active FNDDS release
 func (f Fndds) FnddsQuery(db DBClient, ingredientName string) (*[]models.FnddsFoodItem, error) {
+	return f.FnddsSearch(db, ingredientName, "")
+}
+
+// FnddsSearch performs an websearch_to_tsquery for looser match on description
+// in the given FNDDS release, or the active release if version is empty
+func (f Fndds) FnddsSearch(db DBClient, ingredientName string, version string) (*[]models.FnddsFoodItem, error) {
 	queries := permuteWords(ingredientName)
 	for _, query := range queries {
 		rows, err := db.Query(context.Background(), `
-		SELECT "Food code", "Main food description", "Potassium (mg)", "Phosphorus (mg)", "Energy (kcal)" AS "Calories (kcal)", "Protein (g)", "Carbohydrate (g)"
+		SELECT "Food code", "Main food description", "Potassium (mg)", "Phosphorus (mg)", "Energy (kcal)" AS "Calories (kcal)", "Protein (g)", "Carbohydrate (g)", dataset_version
 		FROM fndds_nutrient_values
 		WHERE to_tsvector('english', description || ' ' || "Main food description" || ' ' || "WWEIA Category description")
 			  @@ plainto_tsquery('english', $1)
+		  AND dataset_version = COALESCE(NULLIF($2, ''), `+activeVersion+`)
 		ORDER BY ts_rank(
 			to_tsvector('english', description || ' ' || "Main food description" || ' ' || "WWEIA Category description"),
 			plainto_tsquery('english', $1)
 		) DESC
 		LIMIT 5;
-		`, query)
+		`, query, version)
 
 		if err != nil {
 			return nil, fmt.Errorf("query error: %w", err)
@@ -33,22 +44,102 @@ func (f Fndds) FnddsQuery(db DBClient, ingredientName string) (*[]models.FnddsFo
 		var items []models.FnddsFoodItem
 		for rows.Next() {
 			var item models.FnddsFoodItem
-			err := rows.Scan(&item.FoodCode, &item.Description, &item.Potassium, &item.Phosphorus, &item.Calories, &item.Protein, &item.Carbs)
+			err := rows.Scan(&item.FoodCode, &item.Description, &item.Potassium, &item.Phosphorus, &item.Calories, &item.Protein, &item.Carbs, &item.DatasetVersion)
 			if err != nil {
+				rows.Close()
 				return nil, fmt.Errorf("scan error: %w", err)
 			}
 			items = append(items, item)
 		}
-		if len(items) == 0 {
-			fmt.Println("⚠️ No matches found in database for:", ingredientName)
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("query error: %w", err)
 		}
 		if len(items) > 0 {
 			return &items, nil
 		}
 	}
+	log.Printf("⚠️ No FNDDS matches for %q", ingredientName)
 	return nil, nil
 }
 
+// FnddsLookup fetches the food items for the given food codes from the active
+// FNDDS release, keyed by food code. Codes that do not exist are left out of
+// the map
+func (f Fndds) FnddsLookup(db DBClient, foodCodes []int) (map[int]models.FnddsFoodItem, error) {
+	rows, err := db.Query(context.Background(), `
+		SELECT "Food code", "Main food description", "Potassium (mg)", "Phosphorus (mg)", "Energy (kcal)" AS "Calories (kcal)", "Protein (g)", "Carbohydrate (g)", dataset_version
+		FROM fndds_nutrient_values
+		WHERE "Food code" = ANY($1) AND dataset_version = `+activeVersion+`;
+		`, foodCodes)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := map[int]models.FnddsFoodItem{}
+	for rows.Next() {
+		var item models.FnddsFoodItem
+		err := rows.Scan(&item.FoodCode, &item.Description, &item.Potassium, &item.Phosphorus, &item.Calories, &item.Protein, &item.Carbs, &item.DatasetVersion)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		items[item.FoodCode] = item
+	}
+	return items, rows.Err()
+}
+
+// FnddsPortions fetches the household measures of a food code in dataset
+// order from the active FNDDS release
+func (f Fndds) FnddsPortions(db DBClient, foodCode int) ([]models.FnddsPortion, error) {
+	rows, err := db.Query(context.Background(), `
+		SELECT "Food code", "Seq num", "Portion description", "Portion weight (g)"
+		FROM fndds_portions_weights
+		WHERE "Food code" = $1 AND dataset_version = `+activeVersion+`
+		ORDER BY "Seq num";
+		`, foodCode)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var portions []models.FnddsPortion
+	for rows.Next() {
+		var p models.FnddsPortion
+		if err := rows.Scan(&p.FoodCode, &p.SeqNum, &p.Description, &p.Grams); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		portions = append(portions, p)
+	}
+	return portions, rows.Err()
+}
+
+// FnddsPortionsLookup fetches the household measures of many food codes at
+// once from the active FNDDS release, keyed by food code and in dataset
+// order. Codes without portions are left out of the map
+func (f Fndds) FnddsPortionsLookup(db DBClient, foodCodes []int) (map[int][]models.FnddsPortion, error) {
+	rows, err := db.Query(context.Background(), `
+		SELECT "Food code", "Seq num", "Portion description", "Portion weight (g)"
+		FROM fndds_portions_weights
+		WHERE "Food code" = ANY($1) AND dataset_version = `+activeVersion+`
+		ORDER BY "Food code", "Seq num";
+		`, foodCodes)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	portions := map[int][]models.FnddsPortion{}
+	for rows.Next() {
+		var p models.FnddsPortion
+		if err := rows.Scan(&p.FoodCode, &p.SeqNum, &p.Description, &p.Grams); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		portions[p.FoodCode] = append(portions[p.FoodCode], p)
+	}
+	return portions, rows.Err()
+}
+
 // helper function to rearrange food descriptions in case no match is found
 func permuteWords(input string) []string {
 	words := strings.Fields(input)
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+/*
+ * Login throttle repository interacts with login_throttle table in postgres
+ */
+
+// GetLockedUntil returns the latest lockout among the keys that has not
+// passed yet, or the zero time if none of them is locked
+func GetLockedUntil(db DBClient, keys []string) (time.Time, error) {
+	var until *time.Time
+	err := db.QueryRow(context.Background(), `
+		SELECT MAX(locked_until) FROM login_throttle
+		WHERE key = ANY($1) AND locked_until > now();
+	`, keys).Scan(&until)
+	if err != nil || until == nil {
+		return time.Time{}, err
+	}
+	return *until, nil
+}
+
+// RecordLoginFailure counts a failed login against the key and returns the
+// failure count. The count starts over when the last failure is older than
+// window
+func RecordLoginFailure(db DBClient, key string, window time.Duration) (int, error) {
+	var failures int
+	err := db.QueryRow(context.Background(), `
+		INSERT INTO login_throttle (key, failures, last_failure_at)
+		VALUES ($1, 1, now())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttle.last_failure_at < now() - $2::interval THEN 1
+				ELSE login_throttle.failures + 1
+			END,
+			last_failure_at = now()
+		RETURNING failures;
+	`, key, fmt.Sprintf("%d seconds", int(window.Seconds()))).Scan(&failures)
+	return failures, err
+}
+
+// LockLogin stops logins for the key until the given time
+func LockLogin(db DBClient, key string, until time.Time) error {
+	_, err := db.Exec(context.Background(),
+		`UPDATE login_throttle SET locked_until = $2 WHERE key = $1;`, key, until)
+	return err
+}
+
+// ClearLoginFailures forgets the failed logins of the key
+func ClearLoginFailures(db DBClient, key string) error {
+	_, err := db.Exec(context.Background(), `DELETE FROM login_throttle WHERE key = $1;`, key)
+	return err
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
@@ -13,8 +14,11 @@ import (
 //✅ What This Tests
 //- InsertCustomMeal() → saves a user-defined favorite meal
 //- InsertLoggedMeal() → logs a real meal with ingredients + totals
-//- GetMealsByUserID() → retrieves meals by user ID and mealType
-//- DeleteMealByName() → removes a named meal for a user
+//- DeleteMealsByID() → removes a user's meals by ID
+//- StreamMeals() + StreamDailyNutrientTotals() → every meal and day, for exports
+//- InsertLoggedMeals() → imports many meals with their totals at once
+//- ListMeals() → filtered pages of meals continued by cursor, with a total
+//- FetchNutrientHistory() → daily totals for one slot or broken down by slot
 //
 //🧪 What’s Covered in This Pattern
 //✅ Database interaction (insert → retrieve → delete)
@@ -52,10 +56,10 @@ func TestInsertAndRetrieveCustomMeal(t *testing.T) {
 		{Name: "Broccoli", Grams: 100, Calories: 50, Protein: 5, Carbs: 10, Phosphorus: 50, Potassium: 200},
 	}
 
-	err := InsertCustomMeal(pool, user.UserID, "My Favorite Tofu Bowl", time.Now(), ingredients)
+	err := InsertCustomMeal(pool, user.UserID, user.UserID, "My Favorite Tofu Bowl", time.Now(), ingredients, "")
 	assert.NoError(t, err)
 
-	meals, err := GetMealsByUserID(pool, user.UserID, "favorite")
+	meals, _, err := ListMeals(pool, user.UserID, &models.MealFilter{MealType: "favorite", Limit: 10})
 	assert.NoError(t, err)
 	assert.NotEmpty(t, meals)
 
@@ -78,13 +82,174 @@ func TestInsertAndDeleteLoggedMeal(t *testing.T) {
 		{Name: "Chicken", Grams: 200, Calories: 300, Protein: 30, Carbs: 0, Phosphorus: 200, Potassium: 400},
 	}
 
-	err := InsertLoggedMeal(pool, user.UserID, "Lunch Chicken", time.Now(), ingredients)
+	err := InsertLoggedMeal(pool, user.UserID, user.UserID, "Lunch Chicken", time.Now(), "", ingredients, "")
 	assert.NoError(t, err)
 
-	meals, err := GetMealsByUserID(pool, user.UserID, "history")
+	meals, _, err := ListMeals(pool, user.UserID, &models.MealFilter{MealType: "history", Limit: 10})
 	assert.NoError(t, err)
 	assert.NotEmpty(t, meals)
 
-	err = DeleteMealByName(pool, user.UserID, "Lunch Chicken")
+	// Another user cannot delete the meal
+	other := createRandomTestUser(t, pool)
+	deleted, err := DeleteMealsByID(pool, other.UserID, []int{meals[0].ID})
 	assert.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	deleted, err = DeleteMealsByID(pool, user.UserID, []int{meals[0].ID})
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, "Lunch Chicken", deleted[0].MealName)
+}
+
+func TestUpdateMealKeepsID(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+
+	mealTime := time.Now().Add(-2 * time.Hour)
+	err := InsertLoggedMeal(pool, user.UserID, user.UserID, "Dinner", mealTime, "", []models.Ingredient{
+		{Name: "Rice", Grams: 1500, Potassium: 525, Phosphorus: 645},
+	}, "")
+	assert.NoError(t, err)
+
+	meals, _, err := ListMeals(pool, user.UserID, &models.MealFilter{MealType: "history", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, meals, 1)
+
+	meal, err := GetMealByID(pool, user.UserID, meals[0].ID)
+	assert.NoError(t, err)
+	meal.Ingredients[0].Grams = 150
+	meal.Ingredients[0].Potassium = 52.5
+
+	updated, err := UpdateMeal(pool, user.UserID, meal)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+
+	// Another user cannot update the meal
+	other := createRandomTestUser(t, pool)
+	updated, err = UpdateMeal(pool, other.UserID, meal)
+	assert.NoError(t, err)
+	assert.False(t, updated)
+}
+
+func TestStreamMealsAndDailyTotals(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+	day := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	banana := []models.Ingredient{{Name: "Banana", Grams: 100, Potassium: 358, Phosphorus: 22}}
+	assert.NoError(t, InsertCustomMeal(pool, user.UserID, user.UserID, "Snack", day, banana, ""))
+	assert.NoError(t, InsertLoggedMeal(pool, user.UserID, user.UserID, "Breakfast", day, models.MealSlotBreakfast, banana, ""))
+	assert.NoError(t, InsertLoggedMeal(pool, user.UserID, user.UserID, "Lunch", day.Add(time.Hour), models.MealSlotLunch, banana, ""))
+
+	var types []string
+	err := StreamMeals(pool, user.UserID, func(m *models.MealGroup, totals map[string]float64) error {
+		types = append(types, m.MealType)
+		assert.Equal(t, 358.0, totals["potassium"])
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"favorite", "history", "history"}, types)
+
+	var days []DailyNutrientTotals
+	err = StreamDailyNutrientTotals(pool, user.UserID, func(d *DailyNutrientTotals) error {
+		days = append(days, *d)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, days, 1)
+	assert.Equal(t, 716.0, days[0].Potassium)
+}
+
+func TestInsertLoggedMeals(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+	day := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	meals := []models.MealGroup{
+		{MealName: "Lunch", Time: day, Ingredients: []models.Ingredient{{Name: "Banana", Grams: 100, Potassium: 358}}},
+		{MealName: "Dinner", Time: day.Add(6 * time.Hour), Ingredients: []models.Ingredient{{Name: "Rice", Grams: 150, Phosphorus: 50}}},
+	}
+	imported, err := InsertLoggedMeals(pool, user.UserID, user.UserID, meals)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), imported)
+
+	history, _, err := ListMeals(pool, user.UserID, &models.MealFilter{MealType: "history", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "Dinner", history[0].MealName)
+	assert.True(t, history[1].Time.Equal(day))
+
+	days, err := FetchNutrientHistory(pool, user.UserID, "2025-01-02T00:00:00", "2025-01-02T23:59:59", "", false)
+	assert.NoError(t, err)
+	assert.Len(t, days, 1)
+	assert.Equal(t, 358.0, days[0].Potassium)
+}
+
+func TestListMeals(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+	day := time.Date(2025, 5, 6, 8, 0, 0, 0, time.UTC)
+	rice := []models.Ingredient{{Name: "White rice", FoodCode: 56205000, Grams: 150, Phosphorus: 50}}
+	banana := []models.Ingredient{{Name: "Banana", Grams: 100, Potassium: 358}}
+	assert.NoError(t, InsertLoggedMeal(pool, user.UserID, user.UserID, "Rice bowl", day, models.MealSlotBreakfast, rice, ""))
+	assert.NoError(t, InsertLoggedMeal(pool, user.UserID, user.UserID, "100% banana", day.Add(4*time.Hour), "", banana, ""))
+	assert.NoError(t, InsertLoggedMeal(pool, user.UserID, user.UserID, "Rice bowl", day.AddDate(0, 0, 1), models.MealSlotDinner, rice, ""))
+
+	// Pages continue after the cursor until every meal is listed
+	page, total, err := ListMeals(pool, user.UserID, &models.MealFilter{MealType: "history", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, page, 2)
+	last := page[1]
+	page, _, err = ListMeals(pool, user.UserID, &models.MealFilter{MealType: "history", Limit: 2, AfterTime: last.Time, AfterID: last.ID})
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.True(t, page[0].Time.Equal(day))
+
+	filters := map[string]*models.MealFilter{
+		"range":      {From: day, To: day.AddDate(0, 0, 1)},
+		"name":       {Query: "BOWL"},
+		"percent":    {Query: "100%"},
+		"ingredient": {Ingredient: "rice"},
+		"food code":  {FoodCode: 56205000},
+	}
+	want := map[string]int{"range": 2, "name": 2, "percent": 1, "ingredient": 2, "food code": 2}
+	for name, f := range filters {
+		f.MealType, f.Limit = "history", 10
+		meals, total, err := ListMeals(pool, user.UserID, f)
+		assert.NoError(t, err, name)
+		assert.Equal(t, want[name], total, name)
+		assert.Len(t, meals, want[name], name)
+	}
+}
+
+func TestFetchNutrientHistoryBySlot(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+	day := time.Date(2025, 6, 7, 8, 0, 0, 0, time.UTC)
+	banana := []models.Ingredient{{Name: "Banana", Grams: 100, Potassium: 358, Phosphorus: 22}}
+	assert.NoError(t, InsertLoggedMeal(pool, user.UserID, user.UserID, "Oats", day, models.MealSlotBreakfast, banana, ""))
+	assert.NoError(t, InsertLoggedMeal(pool, user.UserID, user.UserID, "Stew", day.Add(10*time.Hour), models.MealSlotDinner, banana, ""))
+	assert.NoError(t, InsertLoggedMeal(pool, user.UserID, user.UserID, "Stew again", day.Add(11*time.Hour), models.MealSlotDinner, banana, ""))
+	assert.NoError(t, InsertLoggedMeal(pool, user.UserID, user.UserID, "Something", day.Add(6*time.Hour), "", banana, ""))
+
+	days, err := FetchNutrientHistory(pool, user.UserID, "2025-06-07T00:00:00", "2025-06-07T23:59:59", "", true)
+	assert.NoError(t, err)
+	assert.Len(t, days, 1)
+	assert.Equal(t, 358.0*4, days[0].Potassium)
+	assert.Equal(t, 358.0*2, days[0].Slots[models.MealSlotDinner].Potassium)
+	assert.Equal(t, 22.0, days[0].Slots[models.MealSlotBreakfast].Phosphorous)
+	assert.Equal(t, 358.0, days[0].Slots["other"].Potassium)
+
+	days, err = FetchNutrientHistory(pool, user.UserID, "2025-06-07T00:00:00", "2025-06-07T23:59:59", models.MealSlotDinner, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 358.0*2, days[0].Potassium)
+	assert.Nil(t, days[0].Slots)
+
+	// Only logged meals have a slot
+	_, err = pool.Exec(context.Background(), `UPDATE meals SET meal_type = 'favorite' WHERE user_id = $1 AND slot IS NOT NULL`, user.UserID)
+	assert.Error(t, err)
 }
@@ -2,97 +2,229 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
 	"log"
+	"strings"
 	"time"
 )
 
-// InsertMeal inserts a single meal record for a user into the meals table
-func InsertMeal(dbPool DBClient, userID uuid.UUID, foodCode int, mealTime time.Time) error {
-	_, err := dbPool.Exec(context.Background(),
-		`INSERT INTO meals (user_id, food_code, time) VALUES ($1, $2, $3);`,
-		userID, foodCode, mealTime)
-	log.Printf("📦 Inserting ingredient:")
-	return err
+// StreamMeals calls fn with each favorite and history meal of a user and its
+// stored totals, favorites first and oldest first, without holding them all
+func StreamMeals(dbPool DBClient, userID uuid.UUID, fn func(m *models.MealGroup, totals map[string]float64) error) error {
+	rows, err := dbPool.Query(context.Background(), `
+	SELECT id, meal_name, time, meal_type, ingredients, totals, COALESCE(fndds_version, ''), entered_by, COALESCE(slot, '')
+	FROM meals
+	WHERE user_id = $1
+	ORDER BY meal_type, time, id;
+	`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.MealGroup
+		var totals map[string]float64
+		if err := rows.Scan(&m.ID, &m.MealName, &m.Time, &m.MealType, &m.Ingredients, &totals, &m.FnddsVersion, &m.EnteredBy, &m.Slot); err != nil {
+			return err
+		}
+		if err := fn(&m, totals); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
-// GetMealsByUserID fetches all meals for a given user ID
-func GetMealsByUserID(dbPool DBClient, userID uuid.UUID, mealType string) ([]models.MealGroup, error) {
-	query := `
-	SELECT meal_name, time, ingredients
-	FROM meals
+// mealFilterWhere selects the meals of ListMeals, $1 to $8 are the user,
+// meal type, from, to, name pattern, ingredient pattern, food code and slot
+const mealFilterWhere = `
 	WHERE user_id = $1 AND meal_type = $2
-	ORDER BY time DESC;
-	`
+	  AND ($3::timestamptz IS NULL OR time >= $3)
+	  AND ($4::timestamptz IS NULL OR time < $4)
+	  AND ($5 = '' OR meal_name ILIKE $5)
+	  AND ($6 = '' OR EXISTS (
+		SELECT 1 FROM jsonb_array_elements(ingredients) AS i WHERE i->>'name' ILIKE $6
+	  ))
+	  AND ($7 = 0 OR ingredients @> jsonb_build_array(jsonb_build_object('foodCode', $7::int)))
+	  AND ($8 = '' OR slot = $8)
+`
 
-	rows, err := dbPool.Query(context.Background(), query, userID, mealType)
+// ListMeals fetches a page of the user's meals matching the filter, newest
+// first, and how many meals match in all
+func ListMeals(dbPool DBClient, userID uuid.UUID, f *models.MealFilter) ([]models.MealGroup, int, error) {
+	var from, to, afterTime *time.Time
+	if !f.From.IsZero() {
+		from = &f.From
+	}
+	if !f.To.IsZero() {
+		to = &f.To
+	}
+	if !f.AfterTime.IsZero() {
+		afterTime = &f.AfterTime
+	}
+	args := []any{userID, f.MealType, from, to, containsPattern(f.Query), containsPattern(f.Ingredient), f.FoodCode, f.Slot}
+
+	rows, err := dbPool.Query(context.Background(), `
+	SELECT id, meal_name, time, ingredients, COALESCE(fndds_version, ''), entered_by, COALESCE(slot, '')
+	FROM meals`+mealFilterWhere+`
+	  AND ($9::timestamptz IS NULL OR (time, id) < ($9, $10))
+	ORDER BY time DESC, id DESC
+	LIMIT $11;
+	`, append(args, afterTime, f.AfterID, f.Limit)...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var meals []models.MealGroup
+	meals := []models.MealGroup{}
 	for rows.Next() {
-		var m models.MealGroup
-		if err := rows.Scan(&m.MealName, &m.Time, &m.Ingredients); err != nil {
-			return nil, err
+		m := models.MealGroup{MealType: f.MealType}
+		if err := rows.Scan(&m.ID, &m.MealName, &m.Time, &m.Ingredients, &m.FnddsVersion, &m.EnteredBy, &m.Slot); err != nil {
+			return nil, 0, err
 		}
-		// MealType is constant for all rows, fill it
-		m.MealType = mealType
-
 		meals = append(meals, m)
 	}
-	return meals, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = dbPool.QueryRow(context.Background(), `SELECT COUNT(*) FROM meals`+mealFilterWhere+`;`, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	return meals, total, nil
 }
 
-func InsertCustomMeal(dbPool DBClient, userID uuid.UUID, mealName string, mealTime time.Time, ingredients []models.Ingredient) error {
-	// Calculate totals from ingredients
-	var totalK, totalP, totalCals, totalPro, totalCarbs float64
-	for _, ing := range ingredients {
-		totalK += ing.Potassium
-		totalP += ing.Phosphorus
-		totalCals += ing.Calories
-		totalPro += ing.Protein
-		totalCarbs += ing.Carbs
+// containsPattern Returns an ILIKE pattern matching text anywhere, or "" for
+// no text
+func containsPattern(text string) string {
+	if text == "" {
+		return ""
 	}
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+	return "%" + escaped + "%"
+}
 
-	totals := map[string]float64{
-		"potassium":  totalK,
-		"phosphorus": totalP,
-		"calories":   totalCals,
-		"protein":    totalPro,
-		"carbs":      totalCarbs,
+// GetMealByID fetches a single meal owned by the user, returns nil if the meal
+// does not exist or belongs to someone else
+func GetMealByID(dbPool DBClient, userID uuid.UUID, mealID int) (*models.MealGroup, error) {
+	var m models.MealGroup
+	err := dbPool.QueryRow(context.Background(), `
+	SELECT id, meal_name, time, meal_type, ingredients, COALESCE(fndds_version, ''), entered_by, COALESCE(slot, '')
+	FROM meals
+	WHERE id = $1 AND user_id = $2;
+	`, mealID, userID).Scan(&m.ID, &m.MealName, &m.Time, &m.MealType, &m.Ingredients, &m.FnddsVersion, &m.EnteredBy, &m.Slot)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// UpdateMeal replaces the name, time, type, slot, ingredients and FNDDS
+// release of a meal owned by the user and recomputes its totals, returns false
+// if no meal was updated
+func UpdateMeal(dbPool DBClient, userID uuid.UUID, meal *models.MealGroup) (bool, error) {
+	totals := models.SumIngredients(meal.Ingredients)
+
+	cmdTag, err := dbPool.Exec(context.Background(), `
+		UPDATE meals
+		SET meal_name = $1, time = $2, meal_type = $3, ingredients = $4, totals = $5, fndds_version = NULLIF($8, ''), slot = NULLIF($9, '')
+		WHERE id = $6 AND user_id = $7;
+	`, meal.MealName, meal.Time, meal.MealType, meal.Ingredients, totals, meal.ID, userID, meal.FnddsVersion, meal.Slot)
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("✏️ UpdateMeal: id=%d user=%s", meal.ID, userID)
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// InsertCustomMeal saves a favorite meal of userID, enteredBy is who saved it.
+// fnddsVersion is the release its nutrients were computed from, "" for none
+func InsertCustomMeal(dbPool DBClient, userID, enteredBy uuid.UUID, mealName string, mealTime time.Time, ingredients []models.Ingredient, fnddsVersion string) error {
+	// Calculate totals from ingredients
+	totals := models.SumIngredients(ingredients)
 
 	_, err := dbPool.Exec(context.Background(), `
-		INSERT INTO meals (user_id, meal_name, time, meal_type, ingredients, totals)
-		VALUES ($1, $2, $3, 'favorite', $4, $5);
-	`, userID, mealName, mealTime, ingredients, totals)
+		INSERT INTO meals (user_id, meal_name, time, meal_type, ingredients, totals, fndds_version, entered_by)
+		VALUES ($1, $2, $3, 'favorite', $4, $5, NULLIF($6, ''), $7);
+	`, userID, mealName, mealTime, ingredients, totals, fnddsVersion, enteredBy)
 
 	log.Printf("💾 InsertCustomMeal (favorite): name=%s user=%s time=%v", mealName, userID, mealTime)
 	return err
 }
 
 type DailyNutrientTotals struct {
-	Date        time.Time `json:"date"`
-	Potassium   float64   `json:"potassiumTotal"`
-	Phosphorous float64   `json:"phosphorousTotal"`
+	Date        time.Time                       `json:"date"`
+	Potassium   float64                         `json:"potassiumTotal"`
+	Phosphorous float64                         `json:"phosphorousTotal"`
+	Protein     float64                         `json:"proteinTotal"`
+	Calories    float64                         `json:"caloriesTotal"`
+	Usage       map[string]models.NutrientUsage `json:"usage,omitempty"`
+	OverLimit   bool                            `json:"overLimit"`
+	// Slots breaks the day down by meal slot when asked for, meals without a
+	// slot are under "other"
+	Slots map[string]SlotNutrientTotals `json:"slots,omitempty"`
+}
+
+// SlotNutrientTotals are the sums of one meal slot of a day
+type SlotNutrientTotals struct {
+	Potassium   float64 `json:"potassiumTotal"`
+	Phosphorous float64 `json:"phosphorousTotal"`
 }
 
-func FetchNutrientHistory(db DBClient, userID uuid.UUID, start, end string) ([]DailyNutrientTotals, error) {
+// Totals returns the day's sums keyed the same way as meal totals
+func (d *DailyNutrientTotals) Totals() map[string]float64 {
+	return map[string]float64{
+		"potassium":  d.Potassium,
+		"phosphorus": d.Phosphorous,
+		"protein":    d.Protein,
+		"calories":   d.Calories,
+	}
+}
+
+// FetchNutrientHistory sums the user's logged meals of each day between start
+// and end. slot keeps only the meals of that slot, and bySlot fills in the
+// potassium and phosphorus of each slot of the day
+func FetchNutrientHistory(db DBClient, userID uuid.UUID, start, end, slot string, bySlot bool) ([]DailyNutrientTotals, error) {
 	query := `
-		SELECT 
-			DATE(time) AS date,
-			SUM((totals->>'potassium')::float) AS potassium,
-			SUM((totals->>'phosphorus')::float) AS phosphorous
-		FROM meals
-		WHERE user_id = $1 AND meal_type = 'history' AND time BETWEEN $2 AND $3
-		GROUP BY DATE(time)
-		ORDER BY DATE(time)
+		WITH slots AS (
+			SELECT
+				DATE(time) AS date,
+				COALESCE(slot, 'other') AS slot,
+				SUM((totals->>'potassium')::float) AS potassium,
+				SUM((totals->>'phosphorus')::float) AS phosphorous,
+				COALESCE(SUM((totals->>'protein')::float), 0) AS protein,
+				COALESCE(SUM((totals->>'calories')::float), 0) AS calories
+			FROM meals
+			WHERE user_id = $1 AND meal_type = 'history' AND time BETWEEN $2 AND $3
+			  AND ($4 = '' OR slot = $4)
+			GROUP BY DATE(time), COALESCE(slot, 'other')
+		)
+		SELECT
+			date,
+			SUM(potassium),
+			SUM(phosphorous),
+			SUM(protein),
+			SUM(calories),
+			CASE WHEN $5::boolean THEN jsonb_object_agg(slot, jsonb_build_object(
+				'potassiumTotal', COALESCE(potassium, 0),
+				'phosphorousTotal', COALESCE(phosphorous, 0)
+			)) END
+		FROM slots
+		GROUP BY date
+		ORDER BY date
 	`
 
-	rows, err := db.Query(context.Background(), query, userID, start, end)
+	rows, err := db.Query(context.Background(), query, userID, start, end, slot, bySlot)
 	if err != nil {
 		return nil, err
 	}
@@ -101,7 +233,7 @@ func FetchNutrientHistory(db DBClient, userID uuid.UUID, start, end string) ([]D
 	var results []DailyNutrientTotals
 	for rows.Next() {
 		var d DailyNutrientTotals
-		if err := rows.Scan(&d.Date, &d.Potassium, &d.Phosphorous); err != nil {
+		if err := rows.Scan(&d.Date, &d.Potassium, &d.Phosphorous, &d.Protein, &d.Calories, &d.Slots); err != nil {
 			return nil, err
 		}
 		results = append(results, d)
@@ -109,38 +241,121 @@ func FetchNutrientHistory(db DBClient, userID uuid.UUID, start, end string) ([]D
 	return results, nil
 }
 
-func InsertLoggedMeal(dbPool DBClient, userID uuid.UUID, mealName string, mealTime time.Time, ingredients []models.Ingredient) error {
-	// Calculate totals
-	var totalK, totalP, totalCals, totalPro, totalCarbs float64
-	for _, ing := range ingredients {
-		totalK += ing.Potassium
-		totalP += ing.Phosphorus
-		totalCals += ing.Calories
-		totalPro += ing.Protein
-		totalCarbs += ing.Carbs
+// StreamDailyNutrientTotals calls fn with the nutrient totals of every day
+// the user logged meals, oldest first
+func StreamDailyNutrientTotals(db DBClient, userID uuid.UUID, fn func(d *DailyNutrientTotals) error) error {
+	rows, err := db.Query(context.Background(), `
+		SELECT
+			DATE(time) AS date,
+			COALESCE(SUM((totals->>'potassium')::float), 0) AS potassium,
+			COALESCE(SUM((totals->>'phosphorus')::float), 0) AS phosphorous,
+			COALESCE(SUM((totals->>'protein')::float), 0) AS protein,
+			COALESCE(SUM((totals->>'calories')::float), 0) AS calories
+		FROM meals
+		WHERE user_id = $1 AND meal_type = 'history'
+		GROUP BY DATE(time)
+		ORDER BY DATE(time);
+	`, userID)
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
-	totals := map[string]float64{
-		"potassium":  totalK,
-		"phosphorus": totalP,
-		"calories":   totalCals,
-		"protein":    totalPro,
-		"carbs":      totalCarbs,
+	for rows.Next() {
+		var d DailyNutrientTotals
+		if err := rows.Scan(&d.Date, &d.Potassium, &d.Phosphorous, &d.Protein, &d.Calories); err != nil {
+			return err
+		}
+		if err := fn(&d); err != nil {
+			return err
+		}
 	}
+	return rows.Err()
+}
+
+// InsertLoggedMeal logs a meal userID ate as slot, which may be "" for none.
+// enteredBy is who logged it and fnddsVersion the release its nutrients were
+// computed from, "" for none
+func InsertLoggedMeal(dbPool DBClient, userID, enteredBy uuid.UUID, mealName string, mealTime time.Time, slot string, ingredients []models.Ingredient, fnddsVersion string) error {
+	// Calculate totals
+	totals := models.SumIngredients(ingredients)
 
 	_, err := dbPool.Exec(context.Background(), `
-		INSERT INTO meals (user_id, meal_name, time, meal_type, ingredients, totals)
-		VALUES ($1, $2, $3, 'history', $4, $5);
-	`, userID, mealName, mealTime, ingredients, totals)
+		INSERT INTO meals (user_id, meal_name, time, meal_type, ingredients, totals, fndds_version, entered_by, slot)
+		VALUES ($1, $2, $3, 'history', $4, $5, NULLIF($6, ''), $7, NULLIF($8, ''));
+	`, userID, mealName, mealTime, ingredients, totals, fnddsVersion, enteredBy, slot)
 
 	return err
 }
 
-func DeleteMealByName(dbPool DBClient, userID uuid.UUID, mealName string) error {
-	cmdTag, err := dbPool.Exec(context.Background(),
-		`DELETE FROM meals WHERE user_id = $1 AND meal_name = $2;`,
-		userID, mealName)
+// InsertLoggedMeals logs many meals userID ate in a single statement, so
+// either all of them are saved or none are, each with its FnddsVersion.
+// enteredBy is who logged them
+func InsertLoggedMeals(dbPool DBClient, userID, enteredBy uuid.UUID, meals []models.MealGroup) (int64, error) {
+	type mealRecord struct {
+		MealName     string              `json:"meal_name"`
+		Time         time.Time           `json:"time"`
+		Ingredients  []models.Ingredient `json:"ingredients"`
+		Totals       map[string]float64  `json:"totals"`
+		FnddsVersion string              `json:"fndds_version"`
+		Slot         string              `json:"slot"`
+	}
+	records := make([]mealRecord, len(meals))
+	for i, m := range meals {
+		records[i] = mealRecord{
+			MealName:     m.MealName,
+			Time:         m.Time,
+			Ingredients:  m.Ingredients,
+			Totals:       models.SumIngredients(m.Ingredients),
+			FnddsVersion: m.FnddsVersion,
+			Slot:         m.Slot,
+		}
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return 0, err
+	}
 
-	log.Printf("🧹 Deleted %d rows for meal name: %s", cmdTag.RowsAffected(), mealName)
-	return err
+	cmdTag, err := dbPool.Exec(context.Background(), `
+		INSERT INTO meals (user_id, meal_name, time, meal_type, ingredients, totals, fndds_version, entered_by, slot)
+		SELECT $1, m.meal_name, m.time, 'history', m.ingredients, m.totals, NULLIF(m.fndds_version, ''), $2, NULLIF(m.slot, '')
+		FROM jsonb_to_recordset($3::jsonb)
+			AS m(meal_name TEXT, time TIMESTAMPTZ, ingredients JSONB, totals JSONB, fndds_version TEXT, slot TEXT);
+	`, userID, enteredBy, string(data))
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("💾 InsertLoggedMeals: %d meals for user %s", cmdTag.RowsAffected(), userID)
+	return cmdTag.RowsAffected(), nil
+}
+
+// DeleteMealsByID removes the given meals owned by the user and returns the
+// meals that were removed, IDs that do not exist or belong to someone else are
+// left out
+func DeleteMealsByID(dbPool DBClient, userID uuid.UUID, mealIDs []int) ([]models.MealGroup, error) {
+	rows, err := dbPool.Query(context.Background(), `
+		DELETE FROM meals
+		WHERE user_id = $1 AND id = ANY($2)
+		RETURNING id, meal_name, time, meal_type;
+	`, userID, mealIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deleted []models.MealGroup
+	for rows.Next() {
+		var m models.MealGroup
+		if err := rows.Scan(&m.ID, &m.MealName, &m.Time, &m.MealType); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🧹 Deleted %d of %d meals for user %s", len(deleted), len(mealIDs), userID)
+	return deleted, nil
 }
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- UpdateUserEmail() + GetUserByEmail(): lookup ignores case, duplicates fail
+//- CreatePasswordReset() + UsePasswordReset(): tokens work once, a newer
+//  token replaces the older one, expired tokens don't work
+
+func TestUserEmail(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+	other := createRandomTestUser(t, pool)
+	email := "ada_" + uuid.NewString() + "@example.com"
+
+	assert.NoError(t, UpdateUserEmail(pool, user.UserID, email))
+	found, err := GetUserByEmail(pool, "ADA"+email[3:])
+	assert.NoError(t, err)
+	assert.Equal(t, user.UserID, found.UserID)
+	assert.Equal(t, email, found.Email)
+
+	assert.ErrorIs(t, UpdateUserEmail(pool, other.UserID, email), ErrEmailTaken)
+
+	missing, err := GetUserByEmail(pool, "nobody@example.com")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestPasswordReset(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+	expires := time.Now().Add(time.Hour)
+
+	assert.NoError(t, CreatePasswordReset(pool, user.UserID, "old_"+uuid.NewString(), expires))
+	newer := "new_" + uuid.NewString()
+	assert.NoError(t, CreatePasswordReset(pool, user.UserID, newer, expires))
+
+	userID, err := UsePasswordReset(pool, newer)
+	assert.NoError(t, err)
+	assert.Equal(t, user.UserID, userID)
+
+	// Used once
+	userID, err = UsePasswordReset(pool, newer)
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Nil, userID)
+
+	expired := "expired_" + uuid.NewString()
+	assert.NoError(t, CreatePasswordReset(pool, user.UserID, expired, time.Now().Add(-time.Minute)))
+	userID, err = UsePasswordReset(pool, expired)
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Nil, userID)
+}
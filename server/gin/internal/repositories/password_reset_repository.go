@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+/*
+ * Password reset repository interacts with password_resets table in postgres
+ */
+
+// CreatePasswordReset stores the hash of a new reset token, replacing any
+// earlier unused token of the user so only the latest link works
+func CreatePasswordReset(db DBClient, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	_, err := db.Exec(context.Background(), `
+		WITH cleared AS (
+			DELETE FROM password_resets WHERE user_id = $1 AND used_at IS NULL
+		)
+		INSERT INTO password_resets (token_hash, user_id, expires_at)
+		VALUES ($2, $1, $3);
+	`, userID, tokenHash, expiresAt)
+	return err
+}
+
+// UsePasswordReset marks a reset token used and returns its user. Returns
+// uuid.Nil if the token is unknown, expired or already used
+func UsePasswordReset(db DBClient, tokenHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := db.QueryRow(context.Background(), `
+		UPDATE password_resets SET used_at = now()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		RETURNING user_id;
+	`, tokenHash).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	return userID, err
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- UpdateUserRole() + GetUser(): new users are patients, roles can change
+//- CreatePatientLink(): one live invitation per patient and member
+//- AcceptPatientLink() + HasPatientLink(): access starts once accepted
+//- RevokePatientLink(): either side can end the link, access stops
+//- InsertAuditEvent(): events get an ID and timestamp
+//- InsertLoggedMeal(): records the caregiver who entered a meal, which is
+//  kept when the caregiver's account is deleted
+
+func TestPatientLinks(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	patient := createRandomTestUser(t, pool)
+	clinician := createRandomTestUser(t, pool)
+
+	assert.NoError(t, GetUser(pool, clinician))
+	assert.Equal(t, models.RolePatient, clinician.Role)
+	updated, err := UpdateUserRole(pool, clinician.UserID, models.RoleClinician)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+
+	link := &models.PatientLink{ID: uuid.New(), PatientID: patient.UserID, MemberID: clinician.UserID, Kind: models.LinkClinician}
+	assert.NoError(t, CreatePatientLink(pool, link))
+	again := &models.PatientLink{ID: uuid.New(), PatientID: patient.UserID, MemberID: clinician.UserID, Kind: models.LinkClinician}
+	assert.ErrorIs(t, CreatePatientLink(pool, again), ErrLinkExists)
+
+	// Pending until accepted
+	linked, err := HasPatientLink(pool, patient.UserID, clinician.UserID, models.LinkClinician)
+	assert.NoError(t, err)
+	assert.False(t, linked)
+	invitations, err := ListMemberLinks(pool, clinician.UserID, models.LinkClinician, false)
+	assert.NoError(t, err)
+	assert.Len(t, invitations, 1)
+	assert.Equal(t, patient.UserName, invitations[0].User.UserName)
+
+	patientID, err := AcceptPatientLink(pool, clinician.UserID, link.ID, models.LinkClinician)
+	assert.NoError(t, err)
+	assert.Equal(t, patient.UserID, patientID)
+	linked, _ = HasPatientLink(pool, patient.UserID, clinician.UserID, models.LinkClinician)
+	assert.True(t, linked)
+
+	links, err := ListPatientLinks(pool, patient.UserID, models.LinkClinician)
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	assert.NotNil(t, links[0].AcceptedAt)
+
+	revoked, err := RevokePatientLink(pool, patient.UserID, link.ID, models.LinkClinician)
+	assert.NoError(t, err)
+	assert.Equal(t, clinician.UserID, revoked.MemberID)
+	linked, _ = HasPatientLink(pool, patient.UserID, clinician.UserID, models.LinkClinician)
+	assert.False(t, linked)
+
+	event := &models.AuditEvent{ActorID: &clinician.UserID, SubjectID: &patient.UserID, Action: models.AuditPatientMealsRead}
+	assert.NoError(t, InsertAuditEvent(pool, event))
+	assert.NotZero(t, event.ID)
+}
+
+func TestCaregiverEnteredMeal(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	patient := createRandomTestUser(t, pool)
+	caregiver := createRandomTestUser(t, pool)
+
+	err := InsertLoggedMeal(pool, patient.UserID, caregiver.UserID, "Soup", time.Now(), "", []models.Ingredient{{Name: "Soup", Grams: 250}}, "")
+	assert.NoError(t, err)
+	meals, _, err := ListMeals(pool, patient.UserID, &models.MealFilter{MealType: "history", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, meals, 1)
+	assert.Equal(t, caregiver.UserID, *meals[0].EnteredBy)
+
+	_, err = DeleteUser(pool, caregiver.UserID)
+	assert.NoError(t, err)
+	meals, _, err = ListMeals(pool, patient.UserID, &models.MealFilter{MealType: "history", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, meals, 1)
+	assert.Nil(t, meals[0].EnteredBy)
+}
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+)
+
+/*
+ * Patient link repository interacts with patient_links table in postgres
+ */
+
+// ErrLinkExists is returned when the patient already invited or linked the
+// member
+var ErrLinkExists = errors.New("patient link already exists")
+
+// liveLinkIndex is the unique index on live links
+const liveLinkIndex = "idx_patient_links_live"
+
+// CreatePatientLink stores a pending invitation
+func CreatePatientLink(db DBClient, link *models.PatientLink) error {
+	err := db.QueryRow(context.Background(), `
+		INSERT INTO patient_links (link_id, patient_id, member_id, kind)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at;
+	`, link.ID, link.PatientID, link.MemberID, link.Kind).Scan(&link.CreatedAt)
+	if isUniqueViolation(err, liveLinkIndex) {
+		return ErrLinkExists
+	}
+	return err
+}
+
+// ListPatientLinks fetches the live links of a patient with the member of
+// each, oldest first
+func ListPatientLinks(db DBClient, patientID uuid.UUID, kind string) ([]models.PatientLink, error) {
+	return queryPatientLinks(db, `
+		SELECT l.link_id, l.patient_id, l.member_id, l.kind, l.created_at, l.accepted_at,
+			u.user_id, u.user_name, u.first_name, u.last_name
+		FROM patient_links l
+		JOIN users u ON u.user_id = l.member_id
+		WHERE l.patient_id = $1 AND l.kind = $2 AND l.revoked_at IS NULL
+		ORDER BY l.created_at;
+	`, patientID, kind)
+}
+
+// ListMemberLinks fetches the live links of a member with the patient of
+// each, the accepted ones or the pending invitations
+func ListMemberLinks(db DBClient, memberID uuid.UUID, kind string, accepted bool) ([]models.PatientLink, error) {
+	return queryPatientLinks(db, `
+		SELECT l.link_id, l.patient_id, l.member_id, l.kind, l.created_at, l.accepted_at,
+			u.user_id, u.user_name, u.first_name, u.last_name
+		FROM patient_links l
+		JOIN users u ON u.user_id = l.patient_id
+		WHERE l.member_id = $1 AND l.kind = $2 AND l.revoked_at IS NULL
+			AND (l.accepted_at IS NOT NULL) = $3
+		ORDER BY u.last_name, u.first_name;
+	`, memberID, kind, accepted)
+}
+
+func queryPatientLinks(db DBClient, query string, args ...any) ([]models.PatientLink, error) {
+	rows, err := db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.PatientLink{}
+	for rows.Next() {
+		var l models.PatientLink
+		if err := rows.Scan(&l.ID, &l.PatientID, &l.MemberID, &l.Kind, &l.CreatedAt, &l.AcceptedAt,
+			&l.User.UserID, &l.User.UserName, &l.User.FirstName, &l.User.LastName); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// AcceptPatientLink accepts an invitation of kind to a member, returns the
+// patient or uuid.Nil if the member has no such pending invitation
+func AcceptPatientLink(db DBClient, memberID, linkID uuid.UUID, kind string) (uuid.UUID, error) {
+	var patientID uuid.UUID
+	err := db.QueryRow(context.Background(), `
+		UPDATE patient_links SET accepted_at = now()
+		WHERE link_id = $1 AND member_id = $2 AND kind = $3
+			AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING patient_id;
+	`, linkID, memberID, kind).Scan(&patientID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	return patientID, err
+}
+
+// RevokePatientLink ends a live link of kind that userID is either side of,
+// returns the link or nil if there is no such link
+func RevokePatientLink(db DBClient, userID, linkID uuid.UUID, kind string) (*models.PatientLink, error) {
+	l := models.PatientLink{ID: linkID, Kind: kind}
+	err := db.QueryRow(context.Background(), `
+		UPDATE patient_links SET revoked_at = now()
+		WHERE link_id = $1 AND (patient_id = $2 OR member_id = $2) AND kind = $3
+			AND revoked_at IS NULL
+		RETURNING patient_id, member_id;
+	`, linkID, userID, kind).Scan(&l.PatientID, &l.MemberID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// HasPatientLink checks if a member has an accepted, live link to a patient
+func HasPatientLink(db DBClient, patientID, memberID uuid.UUID, kind string) (bool, error) {
+	var linked bool
+	err := db.QueryRow(context.Background(), `
+		SELECT EXISTS (
+			SELECT 1 FROM patient_links
+			WHERE patient_id = $1 AND member_id = $2 AND kind = $3
+				AND accepted_at IS NOT NULL AND revoked_at IS NULL
+		);
+	`, patientID, memberID, kind).Scan(&linked)
+	return linked, err
+}
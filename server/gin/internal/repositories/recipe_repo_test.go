@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- InsertRecipe(): saves a recipe with its totals, setting its ID
+//- GetRecipe() + ListRecipes(): only return the owner's recipes
+//- UpdateRecipe() + DeleteRecipe(): report whether a recipe was changed
+
+func TestRecipeCRUD(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+
+	recipe := &models.Recipe{
+		Name:        "Lentil soup",
+		Servings:    4,
+		CookedGrams: 1200,
+		Ingredients: []models.Ingredient{
+			{Name: "Lentils", Grams: 200, Potassium: 1360, Phosphorus: 560},
+			{Name: "Carrot", Grams: 100, Potassium: 320, Phosphorus: 35},
+		},
+	}
+	err := InsertRecipe(pool, user.UserID, recipe)
+	assert.NoError(t, err)
+	assert.NotZero(t, recipe.ID)
+
+	fetched, err := GetRecipe(pool, user.UserID, recipe.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Lentil soup", fetched.Name)
+	assert.Equal(t, 1200.0, fetched.CookedGrams)
+	assert.Equal(t, 420.0, fetched.PerServing["potassium"])
+
+	other, err := GetRecipe(pool, uuid.New(), recipe.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, other)
+
+	recipe.Servings = 6
+	recipe.CookedGrams = 0
+	updated, err := UpdateRecipe(pool, user.UserID, recipe)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+
+	recipes, err := ListRecipes(pool, user.UserID)
+	assert.NoError(t, err)
+	assert.Len(t, recipes, 1)
+	assert.Equal(t, 6.0, recipes[0].Servings)
+	assert.Zero(t, recipes[0].CookedGrams)
+
+	deleted, err := DeleteRecipe(pool, user.UserID, recipe.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = DeleteRecipe(pool, user.UserID, recipe.ID)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+)
+
+/*
+ * Recipe repository interacts with recipes table in postgres
+ */
+
+const recipeColumns = `id, name, servings, COALESCE(cooked_grams, 0), ingredients, created_at, updated_at`
+
+// scanRecipe Reads the recipeColumns of a row and sets the recipe's totals
+func scanRecipe(row pgx.Row) (*models.Recipe, error) {
+	var r models.Recipe
+	if err := row.Scan(&r.ID, &r.Name, &r.Servings, &r.CookedGrams, &r.Ingredients, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	r.SetTotals()
+	return &r, nil
+}
+
+// cookedGrams Returns the cooked weight to store, NULL when not weighed
+func cookedGrams(r *models.Recipe) *float64 {
+	if r.CookedGrams <= 0 {
+		return nil
+	}
+	return &r.CookedGrams
+}
+
+// InsertRecipe saves a new recipe of userID, setting its ID and timestamps
+func InsertRecipe(db DBClient, userID uuid.UUID, r *models.Recipe) error {
+	r.SetTotals()
+	err := db.QueryRow(context.Background(), `
+		INSERT INTO recipes (user_id, name, servings, cooked_grams, ingredients, totals, fndds_version)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		RETURNING id, created_at, updated_at;
+	`, userID, r.Name, r.Servings, cookedGrams(r), r.Ingredients, r.Totals, r.FnddsVersion).Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🍲 InsertRecipe: id=%d name=%s user=%s", r.ID, r.Name, userID)
+	return nil
+}
+
+// GetRecipe fetches a recipe owned by the user, returns nil if the recipe does
+// not exist or belongs to someone else
+func GetRecipe(db DBClient, userID uuid.UUID, recipeID int) (*models.Recipe, error) {
+	r, err := scanRecipe(db.QueryRow(context.Background(), `
+		SELECT `+recipeColumns+`
+		FROM recipes
+		WHERE id = $1 AND user_id = $2;
+	`, recipeID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// ListRecipes fetches every recipe of the user by name
+func ListRecipes(db DBClient, userID uuid.UUID) ([]models.Recipe, error) {
+	recipes := []models.Recipe{}
+	err := StreamRecipes(db, userID, func(r *models.Recipe) error {
+		recipes = append(recipes, *r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+// StreamRecipes calls fn with each recipe of the user by name, without
+// holding them all
+func StreamRecipes(db DBClient, userID uuid.UUID, fn func(r *models.Recipe) error) error {
+	rows, err := db.Query(context.Background(), `
+		SELECT `+recipeColumns+`
+		FROM recipes
+		WHERE user_id = $1
+		ORDER BY lower(name), id;
+	`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanRecipe(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UpdateRecipe replaces a recipe owned by the user and its totals, returns
+// false if no recipe was updated
+func UpdateRecipe(db DBClient, userID uuid.UUID, r *models.Recipe) (bool, error) {
+	r.SetTotals()
+	err := db.QueryRow(context.Background(), `
+		UPDATE recipes
+		SET name = $3, servings = $4, cooked_grams = $5, ingredients = $6, totals = $7,
+			fndds_version = NULLIF($8, ''), updated_at = now()
+		WHERE id = $1 AND user_id = $2
+		RETURNING created_at, updated_at;
+	`, r.ID, userID, r.Name, r.Servings, cookedGrams(r), r.Ingredients, r.Totals, r.FnddsVersion).Scan(&r.CreatedAt, &r.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteRecipe removes a recipe owned by the user, returns whether a row was
+// removed. Meals logged from the recipe are kept
+func DeleteRecipe(db DBClient, userID uuid.UUID, recipeID int) (bool, error) {
+	cmdTag, err := db.Exec(context.Background(), `
+		DELETE FROM recipes
+		WHERE id = $1 AND user_id = $2;
+	`, recipeID, userID)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
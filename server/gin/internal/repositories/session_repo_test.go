@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- CreateSession() + RotateSession(): the old refresh token stops working
+//- GetSessionByPreviousToken(): finds the session of a rotated out token
+//- RevokeSession() + IsSessionActive(): revoked sessions are not active
+
+func TestSessionRotateAndRevoke(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+	session := &models.Session{ID: uuid.New(), UserID: user.UserID, ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, CreateSession(pool, session, "hash-1"))
+
+	rotated, err := RotateSession(pool, "hash-1", "hash-2", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, rotated.ID)
+
+	rotated, err = RotateSession(pool, "hash-1", "hash-3", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Nil(t, rotated)
+
+	previous, err := GetSessionByPreviousToken(pool, "hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, previous.ID)
+
+	active, err := IsSessionActive(pool, session.ID)
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	revoked, err := RevokeSession(pool, session.ID)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	active, err = IsSessionActive(pool, session.ID)
+	assert.NoError(t, err)
+	assert.False(t, active)
+}
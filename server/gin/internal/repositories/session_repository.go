@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+)
+
+/*
+ * Session repository interacts with user_sessions table in postgres
+ */
+
+// CreateSession inserts a new session with the hash of its refresh token
+func CreateSession(db DBClient, s *models.Session, tokenHash string) error {
+	_, err := db.Exec(context.Background(), `
+		INSERT INTO user_sessions (session_id, user_id, refresh_token_hash, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4, $5);
+	`, s.ID, s.UserID, tokenHash, s.UserAgent, s.ExpiresAt)
+	return err
+}
+
+// RotateSession swaps the refresh token of a live session for a new one and
+// extends it, returns nil if no live session holds the old token
+func RotateSession(db DBClient, oldHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	var s models.Session
+	err := db.QueryRow(context.Background(), `
+		UPDATE user_sessions
+		SET previous_token_hash = refresh_token_hash,
+			refresh_token_hash = $2,
+			rotated_at = now(),
+			expires_at = $3
+		WHERE refresh_token_hash = $1 AND revoked_at IS NULL AND expires_at > now()
+		RETURNING session_id, user_id, rotated_at, expires_at;
+	`, oldHash, newHash, expiresAt).Scan(&s.ID, &s.UserID, &s.RotatedAt, &s.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSessionByPreviousToken finds the live session whose last rotated out
+// refresh token has the given hash, returns nil if there is none
+func GetSessionByPreviousToken(db DBClient, tokenHash string) (*models.Session, error) {
+	var s models.Session
+	err := db.QueryRow(context.Background(), `
+		SELECT session_id, user_id, rotated_at, expires_at
+		FROM user_sessions
+		WHERE previous_token_hash = $1 AND revoked_at IS NULL;
+	`, tokenHash).Scan(&s.ID, &s.UserID, &s.RotatedAt, &s.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// IsSessionActive reports whether the session exists, is not revoked and has
+// not expired
+func IsSessionActive(db DBClient, sessionID uuid.UUID) (bool, error) {
+	var active bool
+	err := db.QueryRow(context.Background(), `
+		SELECT EXISTS (
+			SELECT 1 FROM user_sessions
+			WHERE session_id = $1 AND revoked_at IS NULL AND expires_at > now()
+		);
+	`, sessionID).Scan(&active)
+	return active, err
+}
+
+// RevokeSession ends a session, returns false if it was not live
+func RevokeSession(db DBClient, sessionID uuid.UUID) (bool, error) {
+	cmdTag, err := db.Exec(context.Background(), `
+		UPDATE user_sessions SET revoked_at = now()
+		WHERE session_id = $1 AND revoked_at IS NULL;
+	`, sessionID)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// RevokeOtherSessions ends every live session of a user except the one given
+func RevokeOtherSessions(db DBClient, userID, keep uuid.UUID) error {
+	_, err := db.Exec(context.Background(), `
+		UPDATE user_sessions SET revoked_at = now()
+		WHERE user_id = $1 AND session_id <> $2 AND revoked_at IS NULL;
+	`, userID, keep)
+	return err
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- UpsertNutrientTargets(): creates then replaces a user's daily limits
+//- GetNutrientTargets(): returns nil before any limits are set
+//- DeleteNutrientTargets(): reports whether a row was removed
+
+func TestUpsertAndDeleteNutrientTargets(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+
+	targets, err := GetNutrientTargets(pool, user.UserID)
+	assert.NoError(t, err)
+	assert.Nil(t, targets)
+
+	err = UpsertNutrientTargets(pool, user.UserID, &models.NutrientTargets{CKDStage: 3, Potassium: 2000, Phosphorus: 800})
+	assert.NoError(t, err)
+	err = UpsertNutrientTargets(pool, user.UserID, &models.NutrientTargets{CKDStage: 4, Potassium: 1500, Phosphorus: 700})
+	assert.NoError(t, err)
+
+	targets, err = GetNutrientTargets(pool, user.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, targets.CKDStage)
+	assert.Equal(t, 1500.0, targets.Potassium)
+
+	deleted, err := DeleteNutrientTargets(pool, user.UserID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = DeleteNutrientTargets(pool, user.UserID)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+)
+
+/*
+ * Target repository interacts with nutrient_targets table in postgres
+ */
+
+// GetNutrientTargets fetches the daily limits of a user, returns nil targets
+// if the user has not set any
+func GetNutrientTargets(db DBClient, userID uuid.UUID) (*models.NutrientTargets, error) {
+	var t models.NutrientTargets
+	var stage *int
+	err := db.QueryRow(context.Background(), `
+		SELECT ckd_stage, potassium, phosphorus, protein, calories, updated_at
+		FROM nutrient_targets
+		WHERE user_id = $1;
+	`, userID).Scan(&stage, &t.Potassium, &t.Phosphorus, &t.Protein, &t.Calories, &t.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stage != nil {
+		t.CKDStage = *stage
+	}
+	return &t, nil
+}
+
+// UpsertNutrientTargets creates or replaces the daily limits of a user
+func UpsertNutrientTargets(db DBClient, userID uuid.UUID, t *models.NutrientTargets) error {
+	var stage *int
+	if t.CKDStage > 0 {
+		stage = &t.CKDStage
+	}
+	_, err := db.Exec(context.Background(), `
+		INSERT INTO nutrient_targets (user_id, ckd_stage, potassium, phosphorus, protein, calories, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now())
+		ON CONFLICT (user_id) DO UPDATE SET
+			ckd_stage = EXCLUDED.ckd_stage,
+			potassium = EXCLUDED.potassium,
+			phosphorus = EXCLUDED.phosphorus,
+			protein = EXCLUDED.protein,
+			calories = EXCLUDED.calories,
+			updated_at = now();
+	`, userID, stage, t.Potassium, t.Phosphorus, t.Protein, t.Calories)
+	return err
+}
+
+// DeleteNutrientTargets removes the daily limits of a user, returns whether a
+// row was removed
+func DeleteNutrientTargets(db DBClient, userID uuid.UUID) (bool, error) {
+	cmdTag, err := db.Exec(context.Background(),
+		`DELETE FROM nutrient_targets WHERE user_id = $1;`, userID)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
@@ -5,7 +5,9 @@ import (
 	"os"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kimsh02/kay-phos/server/gin/internal/migrations"
 )
 
 func SetupTestDB(t *testing.T) *pgxpool.Pool {
@@ -16,6 +18,17 @@ func SetupTestDB(t *testing.T) *pgxpool.Pool {
 		t.Fatal("DATABASE_URL must be set for tests")
 	}
 
+	// Bring the test schema up to date before preparing statements
+	conn, err := pgx.Connect(context.Background(), dbURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	_, err = migrations.Up(context.Background(), conn)
+	conn.Close(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
 	config, err := pgxpool.ParseConfig(dbURL)
 	if err != nil {
 		t.Fatalf("Failed to parse test db URL: %v", err)
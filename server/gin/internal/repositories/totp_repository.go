@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+)
+
+/*
+ * TOTP repository interacts with user_totp and totp_recovery_codes tables in
+ * postgres
+ */
+
+// GetTOTP fetches the TOTP secret of a user, returns nil if the user has not
+// started enrolling
+func GetTOTP(db DBClient, userID uuid.UUID) (*models.TOTP, error) {
+	var t models.TOTP
+	err := db.QueryRow(context.Background(), `
+		SELECT secret, enabled_at IS NOT NULL, last_used_step
+		FROM user_totp
+		WHERE user_id = $1;
+	`, userID).Scan(&t.Secret, &t.Enabled, &t.LastUsedStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SavePendingTOTP stores a new secret waiting for its first code, replacing
+// an earlier unfinished enrollment. Returns false if TOTP is already enabled
+func SavePendingTOTP(db DBClient, userID uuid.UUID, secret string) (bool, error) {
+	cmdTag, err := db.Exec(context.Background(), `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			last_used_step = 0,
+			created_at = now()
+		WHERE user_totp.enabled_at IS NULL;
+	`, userID, secret)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// EnableTOTP turns on a pending enrollment and replaces the recovery codes,
+// in one statement. Returns false if there was no pending enrollment
+func EnableTOTP(db DBClient, userID uuid.UUID, step int64, codeHashes []string) (bool, error) {
+	var enabled int
+	err := db.QueryRow(context.Background(), `
+		WITH enabled AS (
+			UPDATE user_totp SET enabled_at = now(), last_used_step = $2
+			WHERE user_id = $1 AND enabled_at IS NULL
+			RETURNING user_id
+		), cleared AS (
+			DELETE FROM totp_recovery_codes WHERE user_id IN (SELECT user_id FROM enabled)
+		), inserted AS (
+			INSERT INTO totp_recovery_codes (user_id, code_hash)
+			SELECT enabled.user_id, hash FROM enabled, unnest($3::text[]) AS hash
+		)
+		SELECT count(*) FROM enabled;
+	`, userID, step, codeHashes).Scan(&enabled)
+	return enabled > 0, err
+}
+
+// UseTOTPStep records the time step of an accepted code. Returns false if a
+// code of that step or a later one was already used
+func UseTOTPStep(db DBClient, userID uuid.UUID, step int64) (bool, error) {
+	cmdTag, err := db.Exec(context.Background(), `
+		UPDATE user_totp SET last_used_step = $2
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2;
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// GetUnusedRecoveryCodes fetches the recovery codes a user has left
+func GetUnusedRecoveryCodes(db DBClient, userID uuid.UUID) ([]models.RecoveryCode, error) {
+	rows, err := db.Query(context.Background(), `
+		SELECT id, code_hash FROM totp_recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL;
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []models.RecoveryCode
+	for rows.Next() {
+		var code models.RecoveryCode
+		if err := rows.Scan(&code.ID, &code.Hash); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
+// UseRecoveryCode marks a recovery code used, returns false if it already was
+func UseRecoveryCode(db DBClient, id int) (bool, error) {
+	cmdTag, err := db.Exec(context.Background(),
+		`UPDATE totp_recovery_codes SET used_at = now() WHERE id = $1 AND used_at IS NULL;`, id)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// DeleteTOTP turns off TOTP for a user along with their recovery codes
+func DeleteTOTP(db DBClient, userID uuid.UUID) (bool, error) {
+	cmdTag, err := db.Exec(context.Background(), `DELETE FROM user_totp WHERE user_id = $1;`, userID)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
@@ -5,11 +5,13 @@ import (
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 //✅ What This Tests
 //- CreateUser(): inserts new user with hashed password
 //- GetUser(): retrieves user by username or UUID
+//- UpdateUserNames() + DeleteUser(): meals go with the deleted user
 //
 //🧪 What’s Covered in This Pattern
 //✅ Positive insert + fetch by username
@@ -72,3 +74,28 @@ func TestDuplicateUsernameFails(t *testing.T) {
 	err = CreateUser(pool, user2)
 	assert.Error(t, err, "Expected error when inserting duplicate username")
 }
+
+func TestUpdateAndDeleteUser(t *testing.T) {
+	pool := SetupTestDB(t)
+
+	user := createRandomTestUser(t, pool)
+	err := InsertCustomMeal(pool, user.UserID, user.UserID, "Rice Bowl", time.Now(), []models.Ingredient{{Name: "Rice", Grams: 100}}, "")
+	assert.NoError(t, err)
+
+	updated, err := UpdateUserNames(pool, user.UserID, "New", "Name")
+	assert.NoError(t, err)
+	assert.True(t, updated)
+
+	fetched := &models.User{UserID: user.UserID}
+	assert.NoError(t, GetUser(pool, fetched))
+	assert.Equal(t, "New", fetched.FirstName)
+
+	deleted, err := DeleteUser(pool, user.UserID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	// Meals cascade with the user
+	meals, _, err := ListMeals(pool, user.UserID, &models.MealFilter{MealType: "favorite", Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, meals)
+}
@@ -7,6 +7,8 @@ import (
 	"log"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
 )
 
@@ -14,6 +16,18 @@ import (
  * User repository interacts with users table in postgres
  */
 
+// ErrEmailTaken is returned when another user already has the email address
+var ErrEmailTaken = errors.New("email is already in use")
+
+// emailIndex is the unique index on lower(email)
+const emailIndex = "idx_users_email"
+
+// isUniqueViolation Checks if err broke the given unique constraint or index
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
 // checks if username exists in users table
 // func CheckUserNameExists(dbPool *pgxpool.Pool, username *string) bool {
 // 	var exists bool
@@ -38,7 +52,7 @@ func GetUser(dbPool DBClient, user *models.User) error {
 		query = "user_select_userid_query"
 	}
 	row := dbPool.QueryRow(context.Background(), query, byValue)
-	if err := row.Scan(&user.FirstName, &user.LastName, &user.UserName, &user.UserID, &user.HashedPassword); err != nil {
+	if err := row.Scan(&user.FirstName, &user.LastName, &user.UserName, &user.UserID, &user.HashedPassword, &user.Email, &user.Role); err != nil {
 		if err != sql.ErrNoRows {
 			return errors.New("Invalid username.")
 		} else {
@@ -51,11 +65,98 @@ func GetUser(dbPool DBClient, user *models.User) error {
 // create user in users table
 func CreateUser(dbPool DBClient, user *models.User) error {
 	// Insert user into db
-	_, err := dbPool.Exec(context.Background(), "user_insert_query", user.FirstName, user.LastName, user.UserName, user.UserID, user.HashedPassword)
+	_, err := dbPool.Exec(context.Background(), "user_insert_query", user.FirstName, user.LastName, user.UserName, user.UserID, user.HashedPassword, user.Email)
 	if err != nil {
+		if isUniqueViolation(err, emailIndex) {
+			return ErrEmailTaken
+		}
 		log.Println("Error inserting user into database.")
 		log.Println(err)
 		return err
 	}
 	return nil
 }
+
+// UpdateUserNames changes the first and last name of a user, returns false if
+// the user does not exist
+func UpdateUserNames(dbPool DBClient, userID uuid.UUID, firstName, lastName string) (bool, error) {
+	cmdTag, err := dbPool.Exec(context.Background(),
+		`UPDATE users SET first_name = $2, last_name = $3 WHERE user_id = $1;`,
+		userID, firstName, lastName)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// GetUserByEmail fetches the user with an email address, ignoring case.
+// Returns nil if there is none
+func GetUserByEmail(dbPool DBClient, email string) (*models.User, error) {
+	var user models.User
+	err := dbPool.QueryRow(context.Background(), `
+		SELECT first_name, last_name, user_name, user_id, hashed_password, email, role
+		FROM users
+		WHERE lower(email) = lower($1);
+	`, email).Scan(&user.FirstName, &user.LastName, &user.UserName, &user.UserID, &user.HashedPassword, &user.Email, &user.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUserEmail changes the email of a user, an empty email removes it
+func UpdateUserEmail(dbPool DBClient, userID uuid.UUID, email string) error {
+	_, err := dbPool.Exec(context.Background(),
+		`UPDATE users SET email = NULLIF($2, '') WHERE user_id = $1;`,
+		userID, email)
+	if isUniqueViolation(err, emailIndex) {
+		return ErrEmailTaken
+	}
+	return err
+}
+
+// UpdateUserRole changes the role of a user, returns false if the user does
+// not exist
+func UpdateUserRole(dbPool DBClient, userID uuid.UUID, role string) (bool, error) {
+	cmdTag, err := dbPool.Exec(context.Background(),
+		`UPDATE users SET role = $2 WHERE user_id = $1;`,
+		userID, role)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// UpdateUserRoleByName changes the role of the user with a username, returns
+// false if there is none
+func UpdateUserRoleByName(dbPool DBClient, userName, role string) (bool, error) {
+	cmdTag, err := dbPool.Exec(context.Background(),
+		`UPDATE users SET role = $2 WHERE user_name = $1;`,
+		userName, role)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// UpdatePassword stores a new hashed password for a user
+func UpdatePassword(dbPool DBClient, userID uuid.UUID, hashedPassword string) error {
+	_, err := dbPool.Exec(context.Background(),
+		`UPDATE users SET hashed_password = $2 WHERE user_id = $1;`,
+		userID, hashedPassword)
+	return err
+}
+
+// DeleteUser permanently deletes a user. Their meals, sessions and targets are
+// removed by the ON DELETE CASCADE foreign keys within the same statement
+func DeleteUser(dbPool DBClient, userID uuid.UUID) (bool, error) {
+	cmdTag, err := dbPool.Exec(context.Background(),
+		`DELETE FROM users WHERE user_id = $1;`, userID)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
@@ -112,3 +112,52 @@ func TestLoginAndSignupPostRoutes(t *testing.T) {
 	assert.NotEqual(t, 404, signupResp.Code, "Signup POST route should exist")
 	assert.Less(t, signupResp.Code, 500)
 }
+
+func TestAPITokenRoutes_AreRegistered(t *testing.T) {
+	r := setupRouterForPages()
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	// A typo would silently lock API tokens out of a route
+	for route := range apiTokenRoutes {
+		assert.True(t, registered[route], "%s is not a registered route", route)
+	}
+}
+
+func TestCaregiverRoutes_OnlyAddMeals(t *testing.T) {
+	r := setupRouterForPages()
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for route := range caregiverRoutes {
+		assert.True(t, registered[route], "%s is not a registered route", route)
+		// Meals the patient entered are theirs to change or remove
+		assert.False(t, strings.HasPrefix(route, "PUT ") || strings.HasPrefix(route, "DELETE "), "caregivers may not call %s", route)
+	}
+}
+
+func TestNewRouter_TrustsOnlyListedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clientIP := func() string {
+		r := NewRouter()
+		var ip string
+		r.GET("/ip", func(c *gin.Context) { ip = c.ClientIP() })
+		req := httptest.NewRequest("GET", "/ip", nil)
+		req.Host = "localhost:8080"
+		req.RemoteAddr = "10.0.0.2:4321"
+		req.Header.Set("X-Forwarded-For", "203.0.113.9")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return ip
+	}
+
+	// A spoofed header is ignored unless the peer is a trusted proxy
+	t.Setenv("TRUSTED_PROXIES", "")
+	assert.Equal(t, "10.0.0.2", clientIP())
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+	assert.Equal(t, "203.0.113.9", clientIP())
+}
@@ -2,15 +2,24 @@ package router
 
 import (
 	"github.com/kimsh02/kay-phos/server/gin/internal/middleware"
+	"log"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kimsh02/kay-phos/server/gin/internal/handlers"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
 )
 
 func NewRouter() *gin.Engine {
 	// Set the router as the default one shipped with Gin
 	router := gin.Default()
+	// The login lockout counts failures by client IP, so only listed proxies
+	// may set it with X-Forwarded-For. With none the peer address is used
+	if err := router.SetTrustedProxies(TrustedProxiesFromEnv()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	expectedHosts := map[string]struct{}{
 		"localhost:8080":      {},
 		"server:8080":         {},
@@ -36,22 +45,85 @@ func NewRouter() *gin.Engine {
 		c.Header("Permissions-Policy", "geolocation=(),midi=(),sync-xhr=(),microphone=(),camera=(),magnetometer=(),gyroscope=(),fullscreen=(self),payment=()")
 		c.Next()
 	})
+	// Cross origin API clients, configured with CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORSMiddleware(middleware.CORSOriginsFromEnv(), "/api/", "/dashboard/api/", "/clinician/api/", "/admin/api/"))
 
 	return router
 }
 
+// TrustedProxiesFromEnv Returns the comma separated IPs and CIDRs of
+// TRUSTED_PROXIES, e.g. "10.0.0.0/8,127.0.0.1", nil when unset
+func TrustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// apiTokenRoutes are the only routes personal access tokens may call, with
+// the scope each needs
+var apiTokenRoutes = map[string]string{
+	"GET /dashboard/api/user-meal-history":     models.ScopeMealsRead,
+	"GET /dashboard/api/user-logged-meals":     models.ScopeMealsRead,
+	"POST /dashboard/api/user-meal-history":    models.ScopeMealsWrite,
+	"PUT /dashboard/api/user-meal-history/:id": models.ScopeMealsWrite,
+	"POST /dashboard/api/favorites/:id/log":    models.ScopeMealsWrite,
+	"DELETE /dashboard/api/meals/:id":          models.ScopeMealsWrite,
+	"DELETE /dashboard/api/meals":              models.ScopeMealsWrite,
+	"POST /dashboard/api/import":               models.ScopeMealsWrite,
+	"GET /dashboard/api/recipes":               models.ScopeMealsRead,
+	"GET /dashboard/api/recipes/:id":           models.ScopeMealsRead,
+	"POST /dashboard/api/recipes":              models.ScopeMealsWrite,
+	"PUT /dashboard/api/recipes/:id":           models.ScopeMealsWrite,
+	"DELETE /dashboard/api/recipes/:id":        models.ScopeMealsWrite,
+	"POST /dashboard/api/recipes/:id/log":      models.ScopeMealsWrite,
+	"GET /dashboard/api/nutrient-history":      models.ScopeHistoryRead,
+}
+
+// caregiverRoutes are the only routes a caregiver may call for a patient, by
+// sending the X-Patient-ID header. They add meals, never change or remove the
+// ones the patient entered
+var caregiverRoutes = map[string]bool{
+	"GET /dashboard/api/user-meal-history":  true,
+	"POST /dashboard/api/user-meal-history": true,
+	"POST /dashboard/api/favorites/:id/log": true,
+	"GET /dashboard/api/recipes":            true,
+	"POST /dashboard/api/recipes/:id/log":   true,
+}
+
 func InitRoutes(router *gin.Engine, app *handlers.App) {
 
 	// Set public entry routes
 	router.GET("/", handlers.LoginPage)
 	router.POST("/", handlers.MakeUserHandler(app.LoginUser))
+	router.POST("/login/totp", app.LoginTOTP)
 	router.GET("/new-account/", handlers.NewAccountPage)
 	router.POST("/new-account/", handlers.MakeUserHandler(app.CreateUser))
+	router.POST("/auth/refresh", app.RefreshToken)
+	router.POST("/forgot-password", app.ForgotPassword)
+	router.GET("/reset-password", handlers.ResetPasswordPage)
+	router.POST("/reset-password", app.ResetPassword)
+	router.GET("/.well-known/jwks.json", handlers.JWKS)
+
+	// Token endpoints for API clients, which send Authorization: Bearer
+	// instead of cookies
+	auth := router.Group("/api/auth")
+	{
+		auth.POST("/login", handlers.MakeUserHandler(app.APILogin))
+		auth.POST("/login/totp", app.APILoginTOTP)
+		auth.POST("/refresh", app.APIRefresh)
+		auth.POST("/logout", middleware.ValidateTokenMiddleware(app.DB), app.APILogout)
+	}
 
 	// Set protected routes
 	dashboard := router.Group("/dashboard/")
 	{
-		dashboard.Use(middleware.ValidateTokenMiddleware())
+		dashboard.Use(middleware.ValidateTokenMiddleware(app.DB))
+		dashboard.Use(middleware.APITokenScopeMiddleware(apiTokenRoutes))
+		dashboard.Use(middleware.PatientContextMiddleware(app.DB, caregiverRoutes))
 		dashboard.Use(func(c *gin.Context) {
 			c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
 			c.Header("Pragma", "no-cache")
@@ -65,28 +137,82 @@ func InitRoutes(router *gin.Engine, app *handlers.App) {
 		dashboard.GET("/user-meal-history", handlers.UserMealHistoryPage)
 		dashboard.GET("/foodcode", app.GetFoodCode)
 		dashboard.GET("/api/user-meal-history", app.GetMealHistory)
-		dashboard.DELETE("/user-meal-history", app.DeleteMealEntry)
+		dashboard.DELETE("/api/meals/:id", app.DeleteMealEntry)
+		dashboard.DELETE("/api/meals", app.DeleteMealEntries)
 		dashboard.GET("/search-food", app.SearchFood)
 		dashboard.GET("/autocomplete", app.AutocompleteSuggestions)
-		dashboard.GET("/logout", func(c *gin.Context) {
-			//Clear session token
-			c.SetCookie("token", "", -1, "/", "", false, true)
-			c.Redirect(http.StatusFound, "/")
-		})
+		dashboard.GET("/api/foods/:code/portions", app.GetFoodPortions)
+		dashboard.GET("/logout", app.LogoutUser)
 		dashboard.GET("/settings", handlers.Settings)
 		dashboard.GET("/api/user-info", app.GetCurrentUserInfo)
+		dashboard.PUT("/api/account", app.UpdateAccount)
+		dashboard.PUT("/api/account/password", app.ChangePassword)
+		dashboard.DELETE("/api/account", app.DeleteAccount)
+		dashboard.GET("/api/export", app.ExportUserData)
+		dashboard.GET("/api/totp", app.GetTOTPStatus)
+		dashboard.POST("/api/totp/enroll", app.EnrollTOTP)
+		dashboard.POST("/api/totp/verify", app.VerifyTOTP)
+		dashboard.DELETE("/api/totp", app.DisableTOTP)
+		dashboard.GET("/api/tokens", app.ListAPITokens)
+		dashboard.POST("/api/tokens", app.CreateAPIToken)
+		dashboard.DELETE("/api/tokens/:id", app.RevokeAPIToken)
+		dashboard.GET("/api/clinicians", app.ListClinicians)
+		dashboard.POST("/api/clinicians", app.InviteClinician)
+		dashboard.DELETE("/api/clinicians/:id", app.RemoveClinician)
+		dashboard.GET("/api/caregivers", app.ListCaregivers)
+		dashboard.POST("/api/caregivers", app.InviteCaregiver)
+		dashboard.DELETE("/api/caregivers/:id", app.RemoveCaregiver)
+		dashboard.GET("/api/caregiving/invitations", app.ListCaregivingInvitations)
+		dashboard.POST("/api/caregiving/invitations/:id/accept", app.AcceptCaregivingInvitation)
+		dashboard.GET("/api/caregiving/patients", app.ListCaregivingPatients)
+		dashboard.DELETE("/api/caregiving/:id", app.StopCaregiving)
 		dashboard.GET("/api/user-logged-meals", app.GetLoggedMeals)
 		dashboard.GET("/api/nutrient-history", app.GetNutrientHistory)
+		dashboard.GET("/api/targets", app.GetTargets)
+		dashboard.PUT("/api/targets", app.UpdateTargets)
+		dashboard.DELETE("/api/targets", app.DeleteTargets)
 		// fndds
 		// update: support json requests
 		// test
 		dashboard.POST("/calculate-intake", app.CalculateIntake)
 		dashboard.POST("/api/user-meal-history", app.InsertMealHistory)
+		dashboard.PUT("/api/user-meal-history/:id", app.UpdateMealEntry)
+		dashboard.POST("/api/favorites/:id/log", app.LogFavorite)
+		dashboard.GET("/api/recipes", app.ListRecipes)
+		dashboard.POST("/api/recipes", app.CreateRecipe)
+		dashboard.GET("/api/recipes/:id", app.GetRecipe)
+		dashboard.PUT("/api/recipes/:id", app.UpdateRecipe)
+		dashboard.DELETE("/api/recipes/:id", app.DeleteRecipe)
+		dashboard.POST("/api/recipes/:id/log", app.LogRecipe)
+		dashboard.POST("/api/import", app.ImportMeals)
+
+	}
 
+	// Clinicians read the data of patients who linked them
+	clinician := router.Group("/clinician/api")
+	{
+		clinician.Use(middleware.ValidateTokenMiddleware(app.DB))
+		clinician.Use(middleware.APITokenScopeMiddleware(apiTokenRoutes))
+		clinician.Use(middleware.RequireRole(app.DB, models.RoleClinician))
+		clinician.GET("/invitations", app.ListClinicianInvitations)
+		clinician.POST("/invitations/:id/accept", app.AcceptClinicianInvitation)
+		clinician.DELETE("/invitations/:id", app.DeclineClinicianInvitation)
+		clinician.GET("/patients", app.ListPatients)
+		clinician.GET("/patients/:id/meals", app.GetPatientMeals)
+		clinician.GET("/patients/:id/nutrient-history", app.GetPatientNutrientHistory)
+	}
+
+	admin := router.Group("/admin/api")
+	{
+		admin.Use(middleware.ValidateTokenMiddleware(app.DB))
+		admin.Use(middleware.APITokenScopeMiddleware(apiTokenRoutes))
+		admin.Use(middleware.RequireRole(app.DB, models.RoleAdmin))
+		admin.PUT("/users/:id/role", app.SetUserRole)
+		admin.GET("/audit-events", app.ListAuditEvents)
 	}
 
 	// Invalid paths
-	router.NoRoute(handlers.InvalidPath)
+	router.NoRoute(app.InvalidPath)
 }
 
 func InitStatic(router *gin.Engine) {
@@ -0,0 +1,67 @@
+package services
+
+/*
+ * Personal access tokens, long lived bearer tokens for scripts that are
+ * limited to scopes and can be revoked one by one
+ */
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// APITokenPrefix starts every personal access token, so the middleware can
+// tell them from JWTs and secret scanners can find leaked ones
+const APITokenPrefix = "kp_pat_"
+
+// ErrInvalidAPIToken is returned for unknown, revoked or expired tokens
+var ErrInvalidAPIToken = errors.New("API token is invalid, revoked or expired")
+
+// IsAPIToken Reports whether a bearer token is a personal access token
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// CreateAPIToken Creates a personal access token for a user and returns it
+// with the token value, which is not stored and can't be shown again
+func CreateAPIToken(db repositories.DBClient, userID uuid.UUID, req *models.APITokenRequest) (*models.APIToken, string, error) {
+	// Same random token and hashing as refresh tokens
+	random, _, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+	value := APITokenPrefix + random
+
+	t := &models.APIToken{
+		ID:     uuid.New(),
+		UserID: userID,
+		Name:   req.Name,
+		Scopes: req.Scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		t.ExpiresAt = &expiresAt
+	}
+	if err := repositories.CreateAPIToken(db, t, HashToken(value)); err != nil {
+		return nil, "", err
+	}
+	return t, value, nil
+}
+
+// AuthenticateAPIToken Returns the claims of a request made with a personal
+// access token and records its use
+func AuthenticateAPIToken(db repositories.DBClient, token string) (*models.Claims, error) {
+	t, err := repositories.UseAPIToken(db, HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrInvalidAPIToken
+	}
+	return &models.Claims{UserID: t.UserID.String(), TokenID: t.ID.String(), Scopes: t.Scopes}, nil
+}
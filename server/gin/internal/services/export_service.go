@@ -0,0 +1,311 @@
+package services
+
+/*
+ * Personal data export, a zip of everything stored about a user with a JSON
+ * and a CSV version of each part
+ */
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// Export is the data of one user, loaded enough to know the export can be
+// written. Meals, recipes and daily totals are read from the database while writing
+type Export struct {
+	db      repositories.DBClient
+	user    *models.User
+	targets *models.NutrientTargets
+	created time.Time
+}
+
+// exportProfile is the user without the password hash
+type exportProfile struct {
+	UserID    uuid.UUID `json:"userId"`
+	UserName  string    `json:"username"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+}
+
+// exportMeal is a meal with the totals stored for it
+type exportMeal struct {
+	models.MealGroup
+	Totals map[string]float64 `json:"totals"`
+}
+
+// NewExport Loads the profile and targets of a user to export
+func NewExport(db repositories.DBClient, userID uuid.UUID) (*Export, error) {
+	user := &models.User{UserID: userID}
+	if err := repositories.GetUser(db, user); err != nil {
+		return nil, err
+	}
+	targets, err := repositories.GetNutrientTargets(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &Export{db: db, user: user, targets: targets, created: time.Now()}, nil
+}
+
+// FileName Returns the name the export is downloaded as
+func (e *Export) FileName() string {
+	return "kayphos-export-" + e.created.Format("2006-01-02") + ".zip"
+}
+
+// Write Streams the export as a zip to w. Meals are read from the database
+// once per file that lists them rather than kept in memory
+func (e *Export) Write(w io.Writer) error {
+	z := zip.NewWriter(w)
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"profile.json", e.profileJSON},
+		{"profile.csv", e.profileCSV},
+		{"targets.json", e.targetsJSON},
+		{"targets.csv", e.targetsCSV},
+		{"meals.json", e.mealsJSON},
+		{"meals.csv", e.mealsCSV},
+		{"meal_ingredients.csv", e.ingredientsCSV},
+		{"recipes.json", e.recipesJSON},
+		{"recipes.csv", e.recipesCSV},
+		{"recipe_ingredients.csv", e.recipeIngredientsCSV},
+		{"daily_totals.json", e.dailyTotalsJSON},
+		{"daily_totals.csv", e.dailyTotalsCSV},
+	}
+	for _, f := range files {
+		fw, err := z.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: e.created})
+		if err != nil {
+			return err
+		}
+		if err := f.write(fw); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func (e *Export) profile() exportProfile {
+	return exportProfile{
+		UserID:    e.user.UserID,
+		UserName:  e.user.UserName,
+		FirstName: e.user.FirstName,
+		LastName:  e.user.LastName,
+		Email:     e.user.Email,
+		Role:      e.user.Role,
+	}
+}
+
+func (e *Export) profileJSON(w io.Writer) error {
+	return writeJSON(w, e.profile())
+}
+
+func (e *Export) profileCSV(w io.Writer) error {
+	p := e.profile()
+	return writeCSV(w, []string{"user_id", "username", "first_name", "last_name", "email", "role"}, func(row func(...string) error) error {
+		return row(p.UserID.String(), CSVCell(p.UserName), CSVCell(p.FirstName), CSVCell(p.LastName), CSVCell(p.Email), CSVCell(p.Role))
+	})
+}
+
+func (e *Export) targetsJSON(w io.Writer) error {
+	return writeJSON(w, e.targets)
+}
+
+func (e *Export) targetsCSV(w io.Writer) error {
+	return writeCSV(w, []string{"ckd_stage", "potassium", "phosphorus", "protein", "calories", "updated_at"}, func(row func(...string) error) error {
+		t := e.targets
+		if t == nil {
+			return nil
+		}
+		stage := ""
+		if t.CKDStage > 0 {
+			stage = strconv.Itoa(t.CKDStage)
+		}
+		return row(stage, formatFloat(t.Potassium), formatFloat(t.Phosphorus), formatFloat(t.Protein), formatFloat(t.Calories), t.UpdatedAt.UTC().Format(time.RFC3339))
+	})
+}
+
+func (e *Export) mealsJSON(w io.Writer) error {
+	return writeJSONArray(w, func(item func(any) error) error {
+		return repositories.StreamMeals(e.db, e.user.UserID, func(m *models.MealGroup, totals map[string]float64) error {
+			return item(exportMeal{MealGroup: *m, Totals: totals})
+		})
+	})
+}
+
+func (e *Export) mealsCSV(w io.Writer) error {
+	header := []string{"meal_id", "meal_type", "meal_name", "time", "slot", "potassium", "phosphorus", "protein", "calories", "carbs", "fndds_version", "entered_by"}
+	return writeCSV(w, header, func(row func(...string) error) error {
+		return repositories.StreamMeals(e.db, e.user.UserID, func(m *models.MealGroup, totals map[string]float64) error {
+			enteredBy := ""
+			if m.EnteredBy != nil {
+				enteredBy = m.EnteredBy.String()
+			}
+			return row(strconv.Itoa(m.ID), CSVCell(m.MealType), CSVCell(m.MealName), m.Time.UTC().Format(time.RFC3339), CSVCell(m.Slot),
+				formatFloat(totals["potassium"]), formatFloat(totals["phosphorus"]), formatFloat(totals["protein"]),
+				formatFloat(totals["calories"]), formatFloat(totals["carbs"]), CSVCell(m.FnddsVersion), enteredBy)
+		})
+	})
+}
+
+func (e *Export) ingredientsCSV(w io.Writer) error {
+	header := []string{"meal_id", "meal_type", "meal_name", "time", "name", "food_code", "portion", "quantity", "grams",
+		"potassium", "phosphorus", "protein", "calories", "carbs", "verified"}
+	return writeCSV(w, header, func(row func(...string) error) error {
+		return repositories.StreamMeals(e.db, e.user.UserID, func(m *models.MealGroup, _ map[string]float64) error {
+			for _, ing := range m.Ingredients {
+				fields := append([]string{strconv.Itoa(m.ID), CSVCell(m.MealType), CSVCell(m.MealName), m.Time.UTC().Format(time.RFC3339)}, ingredientFields(ing)...)
+				if err := row(fields...); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func (e *Export) recipesJSON(w io.Writer) error {
+	return writeJSONArray(w, func(item func(any) error) error {
+		return repositories.StreamRecipes(e.db, e.user.UserID, func(r *models.Recipe) error {
+			return item(r)
+		})
+	})
+}
+
+func (e *Export) recipesCSV(w io.Writer) error {
+	header := []string{"recipe_id", "name", "servings", "cooked_grams", "potassium", "phosphorus", "protein", "calories", "carbs",
+		"fndds_version", "created_at", "updated_at"}
+	return writeCSV(w, header, func(row func(...string) error) error {
+		return repositories.StreamRecipes(e.db, e.user.UserID, func(r *models.Recipe) error {
+			return row(strconv.Itoa(r.ID), CSVCell(r.Name), formatFloat(r.Servings), formatFloat(r.CookedGrams),
+				formatFloat(r.Totals["potassium"]), formatFloat(r.Totals["phosphorus"]), formatFloat(r.Totals["protein"]),
+				formatFloat(r.Totals["calories"]), formatFloat(r.Totals["carbs"]), CSVCell(r.FnddsVersion),
+				r.CreatedAt.UTC().Format(time.RFC3339), r.UpdatedAt.UTC().Format(time.RFC3339))
+		})
+	})
+}
+
+func (e *Export) recipeIngredientsCSV(w io.Writer) error {
+	header := []string{"recipe_id", "recipe_name", "name", "food_code", "portion", "quantity", "grams",
+		"potassium", "phosphorus", "protein", "calories", "carbs", "verified"}
+	return writeCSV(w, header, func(row func(...string) error) error {
+		return repositories.StreamRecipes(e.db, e.user.UserID, func(r *models.Recipe) error {
+			for _, ing := range r.Ingredients {
+				if err := row(append([]string{strconv.Itoa(r.ID), CSVCell(r.Name)}, ingredientFields(ing)...)...); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// ingredientFields Returns the CSV fields of an ingredient, from its name to
+// whether it was verified
+func ingredientFields(ing models.Ingredient) []string {
+	foodCode := ""
+	if ing.FoodCode != 0 {
+		foodCode = strconv.Itoa(ing.FoodCode)
+	}
+	return []string{CSVCell(ing.Name), foodCode, CSVCell(ing.Portion), formatFloat(ing.Quantity), formatFloat(ing.Grams),
+		formatFloat(ing.Potassium), formatFloat(ing.Phosphorus), formatFloat(ing.Protein),
+		formatFloat(ing.Calories), formatFloat(ing.Carbs), strconv.FormatBool(ing.Verified)}
+}
+
+// streamDailyTotals Calls fn with each day's totals, compared against the
+// user's targets when they have any
+func (e *Export) streamDailyTotals(fn func(d *repositories.DailyNutrientTotals) error) error {
+	return repositories.StreamDailyNutrientTotals(e.db, e.user.UserID, func(d *repositories.DailyNutrientTotals) error {
+		if e.targets != nil {
+			d.Usage, d.OverLimit = e.targets.Usage(d.Totals())
+		}
+		return fn(d)
+	})
+}
+
+func (e *Export) dailyTotalsJSON(w io.Writer) error {
+	return writeJSONArray(w, func(item func(any) error) error {
+		return e.streamDailyTotals(func(d *repositories.DailyNutrientTotals) error {
+			return item(d)
+		})
+	})
+}
+
+func (e *Export) dailyTotalsCSV(w io.Writer) error {
+	header := []string{"date", "potassium", "phosphorus", "protein", "calories", "over_limit"}
+	return writeCSV(w, header, func(row func(...string) error) error {
+		return e.streamDailyTotals(func(d *repositories.DailyNutrientTotals) error {
+			return row(d.Date.Format("2006-01-02"), formatFloat(d.Potassium), formatFloat(d.Phosphorous),
+				formatFloat(d.Protein), formatFloat(d.Calories), strconv.FormatBool(d.OverLimit))
+		})
+	})
+}
+
+// writeJSON Writes v as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeJSONArray Writes a JSON array of the items each passes to item, one
+// at a time
+func writeJSONArray(w io.Writer, each func(item func(any) error) error) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	sep := "\n  "
+	err := each(func(v any) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		sep = ",\n  "
+		_, err = w.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n]\n")
+	return err
+}
+
+// writeCSV Writes the header and the rows each passes to row
+func writeCSV(w io.Writer, header []string, each func(row func(...string) error) error) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := each(func(fields ...string) error { return cw.Write(fields) }); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// CSVCell Quotes a user supplied value with ' when it starts like a formula,
+// so a spreadsheet opening the file shows it as text
+func CSVCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
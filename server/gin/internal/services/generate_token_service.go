@@ -5,23 +5,57 @@ package services
  */
 
 import (
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/kimsh02/kay-phos/server/gin/internal/models"
 )
 
-// Secret key for signing JWTs Generate JWT token
-func GenerateToken(user *models.User) (string, error) {
-	JwtSecret := []byte(os.Getenv("JWT_SECRET"))
+// AccessTokenTTL is how long an access token is valid, the session is kept
+// alive past it with the refresh token
+const AccessTokenTTL = 15 * time.Minute
+
+// GenerateToken Signs a short lived access token for a session of the user
+func GenerateToken(user *models.User, sessionID uuid.UUID) (string, error) {
 	claims := &models.Claims{
-		UserID: user.UserID.String(),
+		UserID:    user.UserID.String(),
+		SessionID: sessionID.String(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(60 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(JwtSecret)
+	return SignToken(claims)
+}
+
+// MFATokenTTL is how long a user has to enter their code after the password
+const MFATokenTTL = 5 * time.Minute
+
+// mfaAudience marks a token that only proves the password was right
+const mfaAudience = "mfa"
+
+// GenerateMFAToken Signs a token for a user whose password was verified and
+// who still has to pass the second factor. It has no session so the token
+// middleware never accepts it
+func GenerateMFAToken(userID uuid.UUID) (string, error) {
+	claims := &models.Claims{
+		UserID: userID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFATokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return SignToken(claims)
+}
+
+// ParseMFAToken Returns the user of a valid second factor token
+func ParseMFAToken(tokenString string) (uuid.UUID, error) {
+	claims := &models.Claims{}
+	_, err := ParseToken(tokenString, claims, jwt.WithAudience(mfaAudience))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(claims.UserID)
 }
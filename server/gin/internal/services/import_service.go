@@ -0,0 +1,287 @@
+package services
+
+/*
+ * Importing meal history from CSV files, our own simple format or the food
+ * diary exports of MyFitnessPal and similar trackers
+ */
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// MaxImportRows caps the foods one import may hold
+const MaxImportRows = 5000
+
+// importColumns maps the header names each format uses to our columns
+var importColumns = map[string]string{
+	"date":         "date",
+	"time":         "time",
+	"meal":         "meal",
+	"meal name":    "meal",
+	"meal_name":    "meal",
+	"food":         "food",
+	"food name":    "food",
+	"food_name":    "food",
+	"grams":        "grams",
+	"weight (g)":   "grams",
+	"amount":       "amount",
+	"quantity":     "amount",
+	"unit":         "unit",
+	"units":        "unit",
+	"serving":      "serving",
+	"serving size": "serving",
+}
+
+// unitGrams is how many grams a unit of weight is
+var unitGrams = map[string]float64{
+	"g": 1, "gram": 1, "grams": 1,
+	"kg": 1000, "kilogram": 1000, "kilograms": 1000,
+	"mg": 0.001,
+	"oz": 28.3495, "ounce": 28.3495, "ounces": 28.3495,
+	"lb": 453.592, "lbs": 453.592, "pound": 453.592, "pounds": 453.592,
+}
+
+// mealHours is the hour meals of a diary without times are logged at, by
+// meal name
+var mealHours = map[string]int{
+	"breakfast": 8,
+	"lunch":     12,
+	"dinner":    18,
+	"snack":     15,
+	"snacks":    15,
+}
+
+var importDateLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02", "01/02/2006", "1/2/2006"}
+
+// ParseImport Reads the rows of an import file and tells which format it is.
+// Rows that can't be read are returned with Error set, a file that can't be
+// read at all is an error. Dates without a zone are in loc
+func ParseImport(r io.Reader, loc *time.Location) (string, []models.ImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return "", nil, errors.New("file is empty or not a CSV")
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if col, ok := importColumns[name]; ok {
+			if _, seen := cols[col]; !seen {
+				cols[col] = i
+			}
+		}
+	}
+	_, hasGrams := cols["grams"]
+	_, hasAmount := cols["amount"]
+	_, hasServing := cols["serving"]
+	if _, ok := cols["date"]; !ok {
+		return "", nil, errors.New("file has no date column")
+	}
+	if _, ok := cols["food"]; !ok {
+		return "", nil, errors.New("file has no food column")
+	}
+	if !hasGrams && !hasAmount && !hasServing {
+		return "", nil, errors.New("file has no grams, amount or serving size column")
+	}
+	format := models.ImportFormatCSV
+	if !hasGrams {
+		format = models.ImportFormatMyFitnessPal
+	}
+
+	var rows []models.ImportRow
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if isBlank(record) {
+			continue
+		}
+		if len(rows) == MaxImportRows {
+			return "", nil, fmt.Errorf("file has more than %d foods", MaxImportRows)
+		}
+		field := func(col string) string {
+			if i, ok := cols[col]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := models.ImportRow{Line: line, MealName: field("meal"), Food: field("food")}
+		if row.MealName == "" {
+			row.MealName = "Imported meal"
+		}
+		row.Time, err = importTime(field("date"), field("time"), row.MealName, loc)
+		if err == nil {
+			row.Grams, err = importGrams(field("grams"), field("amount"), field("unit"), field("serving"))
+		}
+		switch {
+		case err != nil:
+			row.Error = err.Error()
+		case row.Food == "":
+			row.Error = "no food given"
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return "", nil, errors.New("file has no foods")
+	}
+	return format, rows, nil
+}
+
+// ResolveImport Matches the food of each readable row with FnddsQuery and
+// groups the matched rows into history meals by time and meal name. Foods
+// are only searched once however many rows name them
+func ResolveImport(db repositories.DBClient, repo repositories.FnddsRepo, format string, rows []models.ImportRow) (*models.ImportPreview, error) {
+	preview := &models.ImportPreview{
+		Format:    format,
+		Meals:     []models.MealGroup{},
+		Matched:   []models.ImportRow{},
+		Unmatched: []models.ImportRow{},
+	}
+	foods := map[string]*models.FnddsFoodItem{}
+	meals := map[string]int{}
+	for _, row := range rows {
+		if row.Error != "" {
+			preview.Unmatched = append(preview.Unmatched, row)
+			continue
+		}
+		key := strings.ToLower(row.Food)
+		item, searched := foods[key]
+		if !searched {
+			results, err := repo.FnddsQuery(db, row.Food)
+			if err != nil {
+				return nil, err
+			}
+			if results != nil && len(*results) > 0 {
+				item = &(*results)[0]
+			}
+			foods[key] = item
+		}
+		if item == nil {
+			row.Error = "no matching food"
+			preview.Unmatched = append(preview.Unmatched, row)
+			continue
+		}
+		row.FoodCode, row.Description = item.FoodCode, item.Description
+		preview.Matched = append(preview.Matched, row)
+
+		ingredient := item.ForGrams(row.Grams)
+		ingredient.Name = row.Food
+		mealKey := row.Time.UTC().Format(time.RFC3339) + "\x00" + row.MealName
+		i, ok := meals[mealKey]
+		if !ok {
+			i = len(preview.Meals)
+			meals[mealKey] = i
+			preview.Meals = append(preview.Meals, models.MealGroup{
+				MealName: row.MealName,
+				Time:     row.Time,
+				MealType: "history",
+				// Diaries name their meals after the slot
+				Slot: models.MealSlotFromName(row.MealName),
+			})
+		}
+		preview.Meals[i].Ingredients = append(preview.Meals[i].Ingredients, ingredient)
+	}
+
+	var all []models.Ingredient
+	for i := range preview.Meals {
+		preview.Meals[i].FnddsVersion = derefString(models.FnddsVersion(preview.Meals[i].Ingredients))
+		all = append(all, preview.Meals[i].Ingredients...)
+	}
+	preview.Totals = models.SumIngredients(all)
+	return preview, nil
+}
+
+// importTime Parses the date of a row, with the time column when there is
+// one. Diaries without times get a usual time for the meal
+func importTime(date, clock, mealName string, loc *time.Location) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		t, err := time.ParseInLocation(layout, date, loc)
+		if err != nil {
+			continue
+		}
+		if layout != "2006-01-02" && layout != "01/02/2006" && layout != "1/2/2006" {
+			return t, nil
+		}
+		at := func(h, m, sec int) time.Time {
+			return time.Date(t.Year(), t.Month(), t.Day(), h, m, sec, 0, loc)
+		}
+		if clock != "" {
+			for _, layout := range []string{"15:04", "15:04:05", "3:04 PM", "3:04PM"} {
+				if c, err := time.Parse(layout, strings.ToUpper(clock)); err == nil {
+					return at(c.Hour(), c.Minute(), c.Second()), nil
+				}
+			}
+			return time.Time{}, fmt.Errorf("invalid time %q", clock)
+		}
+		hour, ok := mealHours[strings.ToLower(mealName)]
+		if !ok {
+			hour = 12
+		}
+		return at(hour, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", date)
+}
+
+// importGrams Reads the weight of a row from grams, an amount and unit, or a
+// serving size such as "150 g"
+func importGrams(grams, amount, unit, serving string) (float64, error) {
+	if grams != "" {
+		return positiveGrams(grams, 1)
+	}
+	if amount != "" && unit != "" {
+		factor, ok := unitGrams[strings.ToLower(unit)]
+		if !ok {
+			return 0, fmt.Errorf("unit %q is not a weight", unit)
+		}
+		return positiveGrams(amount, factor)
+	}
+	if fields := strings.Fields(serving); len(fields) == 2 {
+		return importGrams("", fields[0], fields[1], "")
+	}
+	if serving != "" {
+		return 0, fmt.Errorf("serving size %q is not a weight", serving)
+	}
+	return 0, errors.New("no weight given")
+}
+
+func positiveGrams(v string, factor float64) (float64, error) {
+	n, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid weight %q", v)
+	}
+	return n * factor, nil
+}
+
+func isBlank(record []string) bool {
+	for _, f := range record {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//✅ What This Tests
+//- ParseImport(): our CSV and MyFitnessPal-style diaries, weights in other
+//  units, usual times for meals without one, bad rows kept with an error
+//- ResolveImport(): rows group into meals, unmatched foods are set aside,
+//  each food is only searched once
+
+func TestParseImport_CSV(t *testing.T) {
+	file := "Date,Meal Name,Food,Grams\n" +
+		"2025-01-02 07:30,Breakfast,Banana,120\n" +
+		"2025-01-02,Dinner,Rice,-5\n" +
+		"\n" +
+		"yesterday,Lunch,Apple,100\n"
+
+	format, rows, err := ParseImport(strings.NewReader(file), time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportFormatCSV, format)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, time.Date(2025, 1, 2, 7, 30, 0, 0, time.UTC), rows[0].Time)
+	assert.Equal(t, 120.0, rows[0].Grams)
+	assert.Empty(t, rows[0].Error)
+	assert.Contains(t, rows[1].Error, "invalid weight")
+	assert.Equal(t, 5, rows[2].Line)
+	assert.Contains(t, rows[2].Error, "invalid date")
+}
+
+func TestParseImport_MyFitnessPal(t *testing.T) {
+	loc, _ := time.LoadLocation("America/Phoenix")
+	file := "Date,Meal,Food Name,Amount,Units\n" +
+		"01/02/2025,Breakfast,Oatmeal,2,oz\n" +
+		"01/02/2025,Snacks,Apple,1,medium\n"
+
+	format, rows, err := ParseImport(strings.NewReader(file), loc)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportFormatMyFitnessPal, format)
+	assert.InDelta(t, 56.7, rows[0].Grams, 0.01)
+	assert.Equal(t, time.Date(2025, 1, 2, 8, 0, 0, 0, loc), rows[0].Time)
+	assert.Contains(t, rows[1].Error, "not a weight")
+}
+
+func TestParseImport_MissingColumns(t *testing.T) {
+	_, _, err := ParseImport(strings.NewReader("Date,Meal,Calories\n2025-01-02,Lunch,400\n"), time.UTC)
+	assert.Error(t, err)
+}
+
+func TestResolveImport(t *testing.T) {
+	day := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	rows := []models.ImportRow{
+		{Line: 2, Time: day, MealName: "Lunch", Food: "Banana", Grams: 200},
+		{Line: 3, Time: day, MealName: "Lunch", Food: "banana", Grams: 100},
+		{Line: 4, Time: day, MealName: "Lunch", Food: "Unobtainium", Grams: 10},
+		{Line: 5, Time: day.Add(6 * time.Hour), MealName: "Dinner", Food: "Banana", Grams: 100},
+		{Line: 6, Error: "invalid date \"x\""},
+	}
+	banana := []models.FnddsFoodItem{{FoodCode: 63107010, Description: "Banana, raw", Potassium: 358, DatasetVersion: "2021-2023"}}
+	repo := new(repositories.MockFnddsRepo)
+	repo.On("FnddsQuery", mock.Anything, "Banana").Return(&banana, nil)
+	repo.On("FnddsQuery", mock.Anything, "Unobtainium").Return((*[]models.FnddsFoodItem)(nil), nil)
+
+	preview, err := ResolveImport(nil, repo, models.ImportFormatCSV, rows)
+	assert.NoError(t, err)
+	assert.Len(t, preview.Meals, 2)
+	assert.Len(t, preview.Meals[0].Ingredients, 2)
+	assert.Equal(t, "2021-2023", preview.Meals[0].FnddsVersion)
+	assert.Equal(t, models.MealSlotLunch, preview.Meals[0].Slot)
+	assert.Equal(t, models.MealSlotDinner, preview.Meals[1].Slot)
+	assert.Len(t, preview.Matched, 3)
+	assert.Equal(t, 63107010, preview.Matched[0].FoodCode)
+	assert.Len(t, preview.Unmatched, 2)
+	assert.Equal(t, "no matching food", preview.Unmatched[0].Error)
+	assert.InDelta(t, 358*4.0, preview.Totals["potassium"], 0.001)
+	repo.AssertNumberOfCalls(t, "FnddsQuery", 2)
+}
@@ -0,0 +1,246 @@
+package services
+
+/*
+ * Keys that sign and verify JWTs. Every token names the key that signed it in
+ * its kid header, so a new key can take over signing while tokens signed by
+ * the old one stay valid until they expire
+ */
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minHMACSecret is the shortest HS256 secret used without a warning
+const minHMACSecret = 32
+
+// ErrNoSigningKey is returned when tokens are signed before a keyring is set
+var ErrNoSigningKey = errors.New("no JWT signing key is configured")
+
+// SigningKey is one key of the keyring. Keys with only a public half verify
+// tokens but can't sign them
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+// NewHMACKey Returns an HS256 key, the same secret signs and verifies
+func NewHMACKey(id string, secret []byte) (*SigningKey, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("JWT key %q: secret is empty", id)
+	}
+	if len(secret) < minHMACSecret {
+		log.Printf("⚠️ JWT key %q: secret is shorter than %d bytes", id, minHMACSecret)
+	}
+	return &SigningKey{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+// NewPEMKey Returns an EdDSA or RS256 key from a PEM encoded private key, or
+// from a public key for a key that only verifies
+func NewPEMKey(id, alg string, data []byte) (*SigningKey, error) {
+	k := &SigningKey{ID: id}
+	switch alg {
+	case jwt.SigningMethodEdDSA.Alg():
+		k.Method = jwt.SigningMethodEdDSA
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			k.signKey, k.verifyKey = private, private.(ed25519.PrivateKey).Public()
+		} else if public, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+			k.verifyKey = public
+		} else {
+			return nil, fmt.Errorf("JWT key %q: no Ed25519 key in PEM", id)
+		}
+	case jwt.SigningMethodRS256.Alg():
+		k.Method = jwt.SigningMethodRS256
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			k.signKey, k.verifyKey = private, &private.PublicKey
+		} else if public, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+			k.verifyKey = public
+		} else {
+			return nil, fmt.Errorf("JWT key %q: no RSA key in PEM", id)
+		}
+	default:
+		return nil, fmt.Errorf("JWT key %q: unsupported algorithm %q, expected HS256, EdDSA or RS256", id, alg)
+	}
+	return k, nil
+}
+
+// KeyRing signs with one of its keys and verifies with any of them
+type KeyRing struct {
+	signing *SigningKey
+	keys    map[string]*SigningKey
+}
+
+// NewKeyRing Returns a keyring that signs with the key signingID
+func NewKeyRing(signingID string, keys ...*SigningKey) (*KeyRing, error) {
+	r := &KeyRing{keys: map[string]*SigningKey{}}
+	for _, k := range keys {
+		if _, ok := r.keys[k.ID]; ok {
+			return nil, fmt.Errorf("JWT key %q is listed twice", k.ID)
+		}
+		r.keys[k.ID] = k
+	}
+	r.signing = r.keys[signingID]
+	if r.signing == nil {
+		return nil, fmt.Errorf("JWT signing key %q is not configured", signingID)
+	}
+	if r.signing.signKey == nil {
+		return nil, fmt.Errorf("JWT signing key %q has no private key", signingID)
+	}
+	return r, nil
+}
+
+// KeyRingFromEnv Builds the keyring from JWT_KEYS, a comma separated list of
+// kid=alg:value where value is the secret for HS256 and the path of a PEM
+// file for EdDSA and RS256. The key JWT_SIGNING_KEY signs, the first by
+// default. Without JWT_KEYS the single JWT_SECRET is used
+//
+//	JWT_KEYS="2026-10=EdDSA:/run/secrets/jwt.pem,2026-04=HS256:<secret>"
+func KeyRingFromEnv() (*KeyRing, error) {
+	spec := strings.TrimSpace(os.Getenv("JWT_KEYS"))
+	if spec == "" {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("no JWT signing key is configured, set JWT_KEYS or JWT_SECRET")
+		}
+		// Stable across restarts and replicas without giving the secret away
+		sum := sha256.Sum256([]byte(secret))
+		key, err := NewHMACKey(hex.EncodeToString(sum[:4]), []byte(secret))
+		if err != nil {
+			return nil, err
+		}
+		return NewKeyRing(key.ID, key)
+	}
+
+	var keys []*SigningKey
+	for _, entry := range strings.Split(spec, ",") {
+		id, rest, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		alg, value, ok2 := strings.Cut(rest, ":")
+		if !ok || !ok2 || id == "" {
+			return nil, fmt.Errorf("JWT_KEYS entry %q is not kid=alg:value", entry)
+		}
+		var key *SigningKey
+		var err error
+		if alg == jwt.SigningMethodHS256.Alg() {
+			key, err = NewHMACKey(id, []byte(value))
+		} else {
+			var data []byte
+			if data, err = os.ReadFile(value); err != nil {
+				return nil, fmt.Errorf("JWT key %q: %w", id, err)
+			}
+			key, err = NewPEMKey(id, alg, data)
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	signingID := os.Getenv("JWT_SIGNING_KEY")
+	if signingID == "" {
+		signingID = keys[0].ID
+	}
+	return NewKeyRing(signingID, keys...)
+}
+
+// Sign Signs claims with the signing key and names it in the kid header
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(r.signing.Method, claims)
+	token.Header["kid"] = r.signing.ID
+	return token.SignedString(r.signing.signKey)
+}
+
+// Parse Verifies a token against the key named by its kid header
+func (r *KeyRing) Parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, r.keyFunc, opts...)
+}
+
+func (r *KeyRing) keyFunc(token *jwt.Token) (any, error) {
+	id, _ := token.Header["kid"].(string)
+	key := r.keys[id]
+	if key == nil {
+		return nil, fmt.Errorf("unknown JWT key %q", id)
+	}
+	// A token can't pick its own algorithm, or a public key could be used as
+	// an HMAC secret
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("JWT key %q is %s, token is %s", id, key.Method.Alg(), token.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// JWK is the public half of a key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType string `json:"kty"`
+	ID      string `json:"kid"`
+	Use     string `json:"use"`
+	Alg     string `json:"alg"`
+	Curve   string `json:"crv,omitempty"`
+	X       string `json:"x,omitempty"`
+	N       string `json:"n,omitempty"`
+	E       string `json:"e,omitempty"`
+}
+
+// PublicKeys Returns the keys other services can verify tokens with. HMAC
+// secrets are never published
+func (r *KeyRing) PublicKeys() []JWK {
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks := []JWK{}
+	for _, k := range r.keys {
+		jwk := JWK{ID: k.ID, Use: "sig", Alg: k.Method.Alg()}
+		switch public := k.verifyKey.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType, jwk.Curve, jwk.X = "OKP", "Ed25519", b64(public)
+		case *rsa.PublicKey:
+			jwk.KeyType, jwk.N, jwk.E = "RSA", b64(public.N.Bytes()), b64(big.NewInt(int64(public.E)).Bytes())
+		default:
+			continue
+		}
+		jwks = append(jwks, jwk)
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].ID < jwks[j].ID })
+	return jwks
+}
+
+var keyRing atomic.Pointer[KeyRing]
+
+// SetKeyRing Sets the keyring tokens are signed and verified with
+func SetKeyRing(r *KeyRing) {
+	keyRing.Store(r)
+}
+
+// CurrentKeyRing Returns the keyring set with SetKeyRing, nil before that
+func CurrentKeyRing() *KeyRing {
+	return keyRing.Load()
+}
+
+// SignToken Signs claims with the current keyring
+func SignToken(claims jwt.Claims) (string, error) {
+	r := CurrentKeyRing()
+	if r == nil {
+		return "", ErrNoSigningKey
+	}
+	return r.Sign(claims)
+}
+
+// ParseToken Verifies a token with the current keyring
+func ParseToken(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	r := CurrentKeyRing()
+	if r == nil {
+		return nil, ErrNoSigningKey
+	}
+	return r.Parse(tokenString, claims, opts...)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- KeyRing: tokens carry a kid and still verify after the signing key rotates
+//- KeyRing: unknown kids and an algorithm that doesn't match the key fail
+//- KeyRingFromEnv(): refuses to run without a key, reads EdDSA PEM files
+//- PublicKeys(): publishes asymmetric keys, never HMAC secrets
+
+func testClaims() *models.Claims {
+	return &models.Claims{
+		UserID:           "user",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+	}
+}
+
+func writeEdKey(t *testing.T) (string, ed25519.PublicKey) {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return path, public
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	oldKey, _ := NewHMACKey("old", []byte("old-secret-old-secret-old-secret"))
+	newKey, _ := NewHMACKey("new", []byte("new-secret-new-secret-new-secret"))
+	before, _ := NewKeyRing("old", oldKey)
+	after, err := NewKeyRing("new", newKey, oldKey)
+	assert.NoError(t, err)
+
+	oldToken, err := before.Sign(testClaims())
+	assert.NoError(t, err)
+	newToken, _ := after.Sign(testClaims())
+
+	parsed, err := after.Parse(oldToken, &models.Claims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "old", parsed.Header["kid"])
+	parsed, err = after.Parse(newToken, &models.Claims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+
+	// Once the old key is retired its tokens stop working
+	_, err = before.Parse(newToken, &models.Claims{})
+	assert.Error(t, err)
+}
+
+func TestKeyRing_RejectsForgedTokens(t *testing.T) {
+	path, public := writeEdKey(t)
+	data, _ := os.ReadFile(path)
+	key, err := NewPEMKey("ed", "EdDSA", data)
+	assert.NoError(t, err)
+	keys, _ := NewKeyRing("ed", key)
+
+	// The public key used as an HMAC secret
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = "ed"
+	tokenString, _ := forged.SignedString([]byte(public))
+	_, err = keys.Parse(tokenString, &models.Claims{})
+	assert.Error(t, err)
+
+	// No kid
+	unnamed, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte("secret"))
+	_, err = keys.Parse(unnamed, &models.Claims{})
+	assert.Error(t, err)
+}
+
+func TestKeyRingFromEnv(t *testing.T) {
+	t.Setenv("JWT_KEYS", "")
+	t.Setenv("JWT_SECRET", "")
+	_, err := KeyRingFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("JWT_SECRET", "secret-secret-secret-secret-secret")
+	keys, err := KeyRingFromEnv()
+	assert.NoError(t, err)
+	assert.Empty(t, keys.PublicKeys())
+
+	path, _ := writeEdKey(t)
+	t.Setenv("JWT_KEYS", "2026-10=EdDSA:"+path+",2026-04=HS256:old-secret-old-secret-old-secret")
+	keys, err = KeyRingFromEnv()
+	assert.NoError(t, err)
+	tokenString, _ := keys.Sign(testClaims())
+	parsed, err := keys.Parse(tokenString, &models.Claims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "EdDSA", parsed.Method.Alg())
+
+	jwks := keys.PublicKeys()
+	assert.Len(t, jwks, 1)
+	assert.Equal(t, "2026-10", jwks[0].ID)
+	assert.Equal(t, "OKP", jwks[0].KeyType)
+
+	t.Setenv("JWT_SIGNING_KEY", "missing")
+	_, err = KeyRingFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("JWT_SIGNING_KEY", "")
+	t.Setenv("JWT_KEYS", "2026-10=HS256:")
+	_, err = KeyRingFromEnv()
+	assert.Error(t, err)
+}
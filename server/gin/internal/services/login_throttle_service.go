@@ -0,0 +1,103 @@
+package services
+
+/*
+ * Slow down password guessing. Failed logins are counted per username and per
+ * client IP, and past a few free attempts each further failure locks the key
+ * for twice as long as the one before, up to a cap
+ */
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// ThrottlePolicy is how many failures are free and how the lockout grows
+type ThrottlePolicy struct {
+	Free int
+	Base time.Duration
+	Max  time.Duration
+}
+
+var (
+	// AccountThrottle protects a single account from a targeted guess
+	AccountThrottle = ThrottlePolicy{Free: 3, Base: time.Second, Max: 15 * time.Minute}
+	// IPThrottle limits one client spraying guesses over many accounts
+	IPThrottle = ThrottlePolicy{Free: 20, Base: time.Second, Max: time.Hour}
+)
+
+// failureWindow is how long failures are remembered after the last one, it
+// is longer than either cap so the lockout keeps growing
+const failureWindow = 2 * time.Hour
+
+// LockoutFor Returns how long the key is locked after its nth failure
+func (p ThrottlePolicy) LockoutFor(failures int) time.Duration {
+	if failures <= p.Free {
+		return 0
+	}
+	lockout := p.Base
+	for i := p.Free + 1; i < failures; i++ {
+		lockout *= 2
+		if lockout >= p.Max {
+			return p.Max
+		}
+	}
+	return lockout
+}
+
+// LoginRetryAfter Returns how long until the username or IP may try again,
+// 0 if neither is locked
+func LoginRetryAfter(db repositories.DBClient, username, ip string) (time.Duration, error) {
+	until, err := repositories.GetLockedUntil(db, []string{accountKey(username), ipKey(ip)})
+	if err != nil || until.IsZero() {
+		return 0, err
+	}
+	return time.Until(until), nil
+}
+
+// RecordLoginFailure Counts a failed login against the username and the IP
+// and locks whichever has run out of free attempts
+func RecordLoginFailure(db repositories.DBClient, username, ip string) error {
+	return recordAttempt(db, []throttleKey{
+		{accountKey(username), AccountThrottle},
+		{ipKey(ip), IPThrottle},
+	})
+}
+
+// throttleKey is a key attempts are counted against and its policy
+type throttleKey struct {
+	key    string
+	policy ThrottlePolicy
+}
+
+// recordAttempt Counts an attempt against every key and locks whichever has
+// run out of free attempts
+func recordAttempt(db repositories.DBClient, keys []throttleKey) error {
+	for _, k := range keys {
+		failures, err := repositories.RecordLoginFailure(db, k.key, failureWindow)
+		if err != nil {
+			return err
+		}
+		if lockout := k.policy.LockoutFor(failures); lockout > 0 {
+			if err := repositories.LockLogin(db, k.key, time.Now().Add(lockout)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecordLoginSuccess Clears the failures of the username. The IP keeps its
+// count, logging into an own account must not reset a spray across others
+func RecordLoginSuccess(db repositories.DBClient, username string) error {
+	return repositories.ClearLoginFailures(db, accountKey(username))
+}
+
+func accountKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//✅ What This Tests
+//- LockoutFor(): free attempts, doubling lockouts and the cap
+
+func TestLockoutFor(t *testing.T) {
+	policy := ThrottlePolicy{Free: 3, Base: time.Second, Max: 10 * time.Second}
+
+	assert.Equal(t, time.Duration(0), policy.LockoutFor(3))
+	assert.Equal(t, time.Second, policy.LockoutFor(4))
+	assert.Equal(t, 2*time.Second, policy.LockoutFor(5))
+	assert.Equal(t, 8*time.Second, policy.LockoutFor(7))
+	assert.Equal(t, 10*time.Second, policy.LockoutFor(8))
+	assert.Equal(t, 10*time.Second, policy.LockoutFor(1000))
+}
@@ -0,0 +1,330 @@
+package services
+
+/*
+ * Compute ingredient nutrients on the server from FNDDS food codes
+ */
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// Discrepancy is a client sent nutrient value that disagrees with the value
+// computed from FNDDS
+type Discrepancy struct {
+	Ingredient string  `json:"ingredient"`
+	FoodCode   int     `json:"foodCode"`
+	Nutrient   string  `json:"nutrient"`
+	Client     float64 `json:"client"`
+	Server     float64 `json:"server"`
+}
+
+// UnknownFoodCodesError is returned when ingredients reference food codes
+// that are not in FNDDS
+type UnknownFoodCodesError struct {
+	FoodCodes []int
+}
+
+func (e *UnknownFoodCodesError) Error() string {
+	return fmt.Sprintf("unknown food codes: %v", e.FoodCodes)
+}
+
+// UnknownPortionError is returned when a portion does not match any household
+// measure of the food code
+type UnknownPortionError struct {
+	FoodCode  int
+	Portion   string
+	Available []models.FnddsPortion
+}
+
+func (e *UnknownPortionError) Error() string {
+	return fmt.Sprintf("unknown portion %q for food code %d", e.Portion, e.FoodCode)
+}
+
+// InvalidIngredientError is returned when an ingredient lacks what its
+// nutrients are computed from, such as a positive weight
+type InvalidIngredientError struct {
+	Reason string
+}
+
+func (e *InvalidIngredientError) Error() string {
+	return e.Reason
+}
+
+// MatchPortion Finds the portion by sequence number, then by exact description,
+// then by description prefix so "1 cup" matches "1 cup, sliced". Matching is
+// case-insensitive
+func MatchPortion(portions []models.FnddsPortion, portion string) *models.FnddsPortion {
+	portion = strings.TrimSpace(portion)
+	if seq, err := strconv.Atoi(portion); err == nil {
+		for i := range portions {
+			if portions[i].SeqNum == seq {
+				return &portions[i]
+			}
+		}
+	}
+	for i := range portions {
+		if strings.EqualFold(portions[i].Description, portion) {
+			return &portions[i]
+		}
+	}
+	lower := strings.ToLower(portion)
+	for i := range portions {
+		if strings.HasPrefix(strings.ToLower(portions[i].Description), lower) {
+			return &portions[i]
+		}
+	}
+	return nil
+}
+
+// PortionGrams Fetches the household measures of a food code and converts a
+// quantity of one of them to grams, a quantity of 0 counts as 1
+func PortionGrams(db repositories.DBClient, repo repositories.FnddsRepo, foodCode int, portion string, quantity float64) (float64, error) {
+	portions, err := repo.FnddsPortions(db, foodCode)
+	if err != nil {
+		return 0, err
+	}
+	return portionGrams(portions, foodCode, portion, quantity)
+}
+
+// portionGrams Converts a quantity of one of the portions of a food code to
+// grams, a quantity of 0 counts as 1
+func portionGrams(portions []models.FnddsPortion, foodCode int, portion string, quantity float64) (float64, error) {
+	if quantity < 0 {
+		return 0, &InvalidIngredientError{Reason: "quantity cannot be negative"}
+	}
+	if quantity == 0 {
+		quantity = 1
+	}
+	match := MatchPortion(portions, portion)
+	if match == nil {
+		return 0, &UnknownPortionError{FoodCode: foodCode, Portion: portion, Available: portions}
+	}
+	return match.Grams * quantity, nil
+}
+
+// ComputeIngredients Replaces the nutrients of every ingredient that has a
+// food code with values computed from FNDDS and its grams, or its portion and
+// quantity, and reports client values that disagree. Ingredients without a
+// food code keep the nutrients sent, but never the client's claim that they
+// were verified or the release they came from. The FNDDS release the
+// nutrients were looked up in is returned, empty if nothing was looked up
+func ComputeIngredients(db repositories.DBClient, repo repositories.FnddsRepo, ingredients []models.Ingredient) ([]models.Ingredient, string, []Discrepancy, error) {
+	var codes, portionCodes []int
+	computed := make([]models.Ingredient, len(ingredients))
+	copy(computed, ingredients)
+	for i, ing := range ingredients {
+		if ing.FoodCode != 0 {
+			codes = append(codes, ing.FoodCode)
+			if ing.Portion != "" && !slices.Contains(portionCodes, ing.FoodCode) {
+				portionCodes = append(portionCodes, ing.FoodCode)
+			}
+			continue
+		}
+		computed[i].Verified, computed[i].FnddsVersion = false, ""
+	}
+	if len(codes) == 0 {
+		return computed, "", nil, nil
+	}
+
+	items, err := repo.FnddsLookup(db, codes)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	// The portions of every food measured in them come in one query
+	var portions map[int][]models.FnddsPortion
+	if len(portionCodes) > 0 {
+		if portions, err = repo.FnddsPortionsLookup(db, portionCodes); err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	var version string
+	var unknown []int
+	var discrepancies []Discrepancy
+	for i, ing := range ingredients {
+		if ing.FoodCode == 0 {
+			continue
+		}
+		item, ok := items[ing.FoodCode]
+		if !ok {
+			unknown = append(unknown, ing.FoodCode)
+			continue
+		}
+		if ing.Portion != "" {
+			grams, err := portionGrams(portions[ing.FoodCode], ing.FoodCode, ing.Portion, ing.Quantity)
+			if err != nil {
+				return nil, "", nil, err
+			}
+			ing.Grams = grams
+		}
+		if ing.Grams <= 0 {
+			return nil, "", nil, &InvalidIngredientError{Reason: fmt.Sprintf("ingredient %q needs a positive weight in grams", ing.Name)}
+		}
+		if version == "" {
+			version = item.DatasetVersion
+		}
+
+		server := item.ForGrams(ing.Grams)
+		server.Portion, server.Quantity = ing.Portion, ing.Quantity
+		// Keep the name the user gave the ingredient
+		if ing.Name != "" {
+			server.Name = ing.Name
+		}
+		discrepancies = append(discrepancies, compareIngredient(ing, server)...)
+		computed[i] = server
+	}
+	if len(unknown) > 0 {
+		sort.Ints(unknown)
+		return nil, "", nil, &UnknownFoodCodesError{FoodCodes: unknown}
+	}
+	return computed, version, discrepancies, nil
+}
+
+// compareIngredient Lists the nutrients where the client value is off by more
+// than 5% or 1 unit, whichever is larger. Nutrients the client left at 0 were
+// not sent, so there is nothing to compare
+func compareIngredient(client, server models.Ingredient) []Discrepancy {
+	pairs := []struct {
+		nutrient       string
+		client, server float64
+	}{
+		{"potassium", client.Potassium, server.Potassium},
+		{"phosphorus", client.Phosphorus, server.Phosphorus},
+		{"calories", client.Calories, server.Calories},
+		{"protein", client.Protein, server.Protein},
+		{"carbs", client.Carbs, server.Carbs},
+	}
+
+	var out []Discrepancy
+	for _, p := range pairs {
+		if p.client == 0 {
+			continue
+		}
+		tolerance := math.Max(1, 0.05*p.server)
+		if math.Abs(p.client-p.server) > tolerance {
+			out = append(out, Discrepancy{
+				Ingredient: server.Name,
+				FoodCode:   server.FoodCode,
+				Nutrient:   p.nutrient,
+				Client:     p.client,
+				Server:     math.Round(p.server*100) / 100,
+			})
+		}
+	}
+	return out
+}
+
+// ScaleIngredients Returns the ingredients for servings of a meal, with the
+// grams of some ingredients, by index, set outright. Nutrients are scaled in
+// proportion, which is all ingredients without a food code can get; those
+// with one should then go through ComputeIngredients. Ingredients measured in
+// portions scale their quantity unless their grams are set
+func ScaleIngredients(ingredients []models.Ingredient, servings float64, grams map[int]float64) ([]models.Ingredient, error) {
+	if servings <= 0 {
+		return nil, fmt.Errorf("servings must be positive")
+	}
+	for i, g := range grams {
+		if i < 0 || i >= len(ingredients) {
+			return nil, fmt.Errorf("no ingredient %d", i)
+		}
+		if g <= 0 {
+			return nil, fmt.Errorf("ingredient %d needs a positive weight in grams", i)
+		}
+	}
+
+	scaled := make([]models.Ingredient, len(ingredients))
+	for i, ing := range ingredients {
+		factor := servings
+		if g, ok := grams[i]; ok {
+			if ing.Grams <= 0 && ing.FoodCode == 0 {
+				return nil, fmt.Errorf("ingredient %q has no weight to scale from", ing.Name)
+			}
+			if ing.Grams > 0 {
+				factor = g / ing.Grams
+			}
+			ing.Portion, ing.Quantity = "", 0
+			ing.Grams = g
+		} else {
+			ing.Grams *= factor
+			if ing.Portion != "" {
+				if ing.Quantity == 0 {
+					ing.Quantity = 1
+				}
+				ing.Quantity *= factor
+			}
+		}
+		ing.Calories *= factor
+		ing.Protein *= factor
+		ing.Carbs *= factor
+		ing.Phosphorus *= factor
+		ing.Potassium *= factor
+		scaled[i] = ing
+	}
+	return scaled, nil
+}
+
+// ErrFoodNotFound is returned when no FNDDS food matches an ingredient
+var ErrFoodNotFound = errors.New("food not found")
+
+// ResolveFood Computes an ingredient from FNDDS, by its food code with grams
+// or a portion and quantity, or else by searching its name and taking the
+// best match. A name that matches nothing is searched again by its first word
+// so "lemon juice" still finds lemon. The name given is kept
+func ResolveFood(db repositories.DBClient, repo repositories.FnddsRepo, food models.Ingredient) (models.Ingredient, error) {
+	var item models.FnddsFoodItem
+	if food.FoodCode != 0 {
+		items, err := repo.FnddsLookup(db, []int{food.FoodCode})
+		if err != nil {
+			return models.Ingredient{}, err
+		}
+		found, ok := items[food.FoodCode]
+		if !ok {
+			return models.Ingredient{}, &UnknownFoodCodesError{FoodCodes: []int{food.FoodCode}}
+		}
+		if food.Portion != "" {
+			grams, err := PortionGrams(db, repo, food.FoodCode, food.Portion, food.Quantity)
+			if err != nil {
+				return models.Ingredient{}, err
+			}
+			food.Grams = grams
+		}
+		item = found
+	} else {
+		name := strings.TrimSpace(food.Name)
+		if name == "" {
+			return models.Ingredient{}, &InvalidIngredientError{Reason: "ingredient needs a name or a food code"}
+		}
+		items, err := repo.FnddsQuery(db, name)
+		if err != nil || items == nil || len(*items) == 0 {
+			if words := strings.Fields(name); len(words) > 1 {
+				items, err = repo.FnddsQuery(db, words[0])
+			}
+		}
+		if err != nil {
+			return models.Ingredient{}, err
+		}
+		if items == nil || len(*items) == 0 {
+			return models.Ingredient{}, fmt.Errorf("%w: %q", ErrFoodNotFound, name)
+		}
+		item = (*items)[0]
+	}
+	if food.Grams <= 0 {
+		return models.Ingredient{}, &InvalidIngredientError{Reason: fmt.Sprintf("ingredient %q needs a positive weight in grams", food.Name)}
+	}
+
+	ing := item.ForGrams(food.Grams)
+	ing.Portion, ing.Quantity = food.Portion, food.Quantity
+	if food.Name != "" {
+		ing.Name = food.Name
+	}
+	return ing, nil
+}
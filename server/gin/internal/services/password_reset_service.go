@@ -0,0 +1,121 @@
+package services
+
+/*
+ * Forgotten password recovery through single use links sent by email
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/mailer"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// PasswordResetTTL is how long a reset link works
+const PasswordResetTTL = time.Hour
+
+// ErrInvalidResetToken is returned for unknown, expired or used reset tokens
+var ErrInvalidResetToken = errors.New("reset link is invalid or has expired")
+
+var (
+	// ResetEmailThrottle keeps reset links from flooding one inbox
+	ResetEmailThrottle = ThrottlePolicy{Free: 3, Base: time.Minute, Max: time.Hour}
+	// ResetIPThrottle limits one client asking for links to many addresses
+	ResetIPThrottle = ThrottlePolicy{Free: 10, Base: time.Minute, Max: time.Hour}
+)
+
+// PasswordResetRetryAfter Returns how long until the email or IP may ask for
+// another reset link, 0 if neither is locked. Requests share the login
+// throttle table under keys of their own
+func PasswordResetRetryAfter(db repositories.DBClient, email, ip string) (time.Duration, error) {
+	until, err := repositories.GetLockedUntil(db, []string{resetEmailKey(email), resetIPKey(ip)})
+	if err != nil || until.IsZero() {
+		return 0, err
+	}
+	return time.Until(until), nil
+}
+
+// RecordPasswordResetRequest Counts a reset request against the email and
+// the IP, known address or not, and locks whichever has run out of free
+// requests
+func RecordPasswordResetRequest(db repositories.DBClient, email, ip string) error {
+	return recordAttempt(db, []throttleKey{
+		{resetEmailKey(email), ResetEmailThrottle},
+		{resetIPKey(ip), ResetIPThrottle},
+	})
+}
+
+func resetEmailKey(email string) string {
+	return "reset:email:" + strings.ToLower(email)
+}
+
+func resetIPKey(ip string) string {
+	return "reset:ip:" + ip
+}
+
+// RequestPasswordReset Emails a reset link to the user with the address. It
+// does nothing for unknown addresses, callers must not reveal the difference
+func RequestPasswordReset(ctx context.Context, db repositories.DBClient, m mailer.Mailer, email, baseURL string) error {
+	user, err := repositories.GetUserByEmail(db, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		log.Printf("📧 Password reset requested for unknown email")
+		return nil
+	}
+
+	// Same random token and hashing as refresh tokens
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return err
+	}
+	if err := repositories.CreatePasswordReset(db, user.UserID, hash, time.Now().Add(PasswordResetTTL)); err != nil {
+		return err
+	}
+
+	link := baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	return m.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Kay Phos password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password of your Kay Phos account %s.\n"+
+			"Open this link within %d minutes to choose a new password:\n\n%s\n\n"+
+			"If it wasn't you, ignore this email, your password stays the same.\n",
+			user.FirstName, user.UserName, int(PasswordResetTTL.Minutes()), link),
+	})
+}
+
+// ResetPassword Uses up a reset token and sets the new password. Every session
+// of the user is ended, whoever forgot the password may not be the only one
+// logged in. The password is hashed first so a password that can't be does
+// not use up the link
+func ResetPassword(db repositories.DBClient, token, newPassword string) (uuid.UUID, error) {
+	user := &models.User{InputPassword: newPassword}
+	if err := user.SetHashedPassword(); err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := repositories.UsePasswordReset(db, HashToken(token))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if userID == uuid.Nil {
+		return uuid.Nil, ErrInvalidResetToken
+	}
+	if err := repositories.UpdatePassword(db, userID, user.HashedPassword); err != nil {
+		return uuid.Nil, err
+	}
+	if err := repositories.RevokeOtherSessions(db, userID, uuid.Nil); err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+/*
+ * Start, refresh and end login sessions. A session hands out short lived
+ * access tokens and a refresh token that is replaced on every use
+ */
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+)
+
+// RefreshTokenTTL is how long a session lasts without being refreshed
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// rotationGrace is how long a just rotated refresh token is still accepted,
+// so parallel requests that all refresh at once don't look like a replay
+const rotationGrace = 30 * time.Second
+
+// ErrInvalidRefreshToken is returned for unknown, expired, revoked or replayed
+// refresh tokens
+var ErrInvalidRefreshToken = errors.New("refresh token is invalid or has expired")
+
+// SessionTokens are the tokens handed to the client. RefreshToken is empty
+// when the client keeps the one it has
+type SessionTokens struct {
+	AccessToken  string
+	RefreshToken string
+	SessionID    uuid.UUID
+	UserID       uuid.UUID
+}
+
+// Claims Returns the claims of the access token, as the token middleware would
+// set them
+func (t *SessionTokens) Claims() *models.Claims {
+	return &models.Claims{UserID: t.UserID.String(), SessionID: t.SessionID.String()}
+}
+
+// StartSession Creates a session for a user who just logged in
+func StartSession(db repositories.DBClient, user *models.User, userAgent string) (*SessionTokens, error) {
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	session := &models.Session{
+		ID:        uuid.New(),
+		UserID:    user.UserID,
+		UserAgent: userAgent,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}
+	if err := repositories.CreateSession(db, session, hash); err != nil {
+		return nil, err
+	}
+	access, err := GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionTokens{AccessToken: access, RefreshToken: refresh, SessionID: session.ID, UserID: user.UserID}, nil
+}
+
+// RefreshSession Trades a refresh token for a new access token and a new
+// refresh token. Presenting a token that was already rotated out revokes the
+// session, someone else has a copy of it
+func RefreshSession(db repositories.DBClient, refreshToken string) (*SessionTokens, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	oldHash := HashToken(refreshToken)
+	refresh, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := repositories.RotateSession(db, oldHash, newHash, time.Now().Add(RefreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		session, err = repositories.GetSessionByPreviousToken(db, oldHash)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			return nil, ErrInvalidRefreshToken
+		}
+		if time.Since(session.RotatedAt) > rotationGrace || time.Now().After(session.ExpiresAt) {
+			log.Printf("⚠️ Refresh token replayed, revoking session %s", session.ID)
+			if _, err := repositories.RevokeSession(db, session.ID); err != nil {
+				return nil, err
+			}
+			return nil, ErrInvalidRefreshToken
+		}
+		// Lost a race with a parallel refresh, which already sent the new
+		// refresh token
+		refresh = ""
+	}
+
+	access, err := GenerateToken(&models.User{UserID: session.UserID}, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionTokens{AccessToken: access, RefreshToken: refresh, SessionID: session.ID, UserID: session.UserID}, nil
+}
+
+// HashToken Hashes a refresh token for storage, the tokens are random so a
+// plain SHA-256 is enough
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
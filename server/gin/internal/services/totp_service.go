@@ -0,0 +1,169 @@
+package services
+
+/*
+ * RFC 6238 time-based one-time passwords, the defaults every authenticator
+ * app supports: HMAC-SHA1, 6 digits, 30 second steps
+ */
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kimsh02/kay-phos/server/gin/internal/models"
+	"github.com/kimsh02/kay-phos/server/gin/internal/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	totpIssuer = "KayPhos"
+	totpDigits = 6
+	totpPeriod = 30
+	// totpSkew is how many steps either side of now are accepted, for clock
+	// drift between the server and the phone
+	totpSkew = 1
+	// RecoveryCodeCount is how many recovery codes a user gets on enrollment
+	RecoveryCodeCount = 10
+)
+
+// ErrInvalidSecondFactor is returned for a wrong, reused or missing code
+var ErrInvalidSecondFactor = errors.New("invalid authentication code")
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret Returns a random 160 bit secret in base32
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI Returns the otpauth URI authenticator apps read from a QR code
+func TOTPURI(secret, account string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", totpIssuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(totpDigits))
+	v.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(totpIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// TOTPStep Returns the time step t falls in
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// TOTPCode Returns the code of the secret for a time step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000), nil
+}
+
+// MatchTOTP Returns the step of the code if it is valid at t and newer than
+// lastStep, or false
+func MatchTOTP(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	now := TOTPStep(t)
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes Returns new recovery codes to show the user once and
+// their bcrypt hashes to store
+func GenerateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(totpEncoding.EncodeToString(b))
+		codes[i] = raw[:8] + "-" + raw[8:]
+		hash, err := bcrypt.GenerateFromPassword([]byte(raw), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes[i] = string(hash)
+	}
+	return codes, hashes, nil
+}
+
+// VerifySecondFactor Checks a code from the authenticator app, or else a
+// recovery code, of a user with TOTP enabled and uses it up
+func VerifySecondFactor(db repositories.DBClient, userID uuid.UUID, totp *models.TOTP, req *models.TOTPCode) error {
+	if req.Code != "" {
+		step, ok := MatchTOTP(totp.Secret, req.Code, time.Now(), totp.LastUsedStep)
+		if !ok {
+			return ErrInvalidSecondFactor
+		}
+		used, err := repositories.UseTOTPStep(db, userID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidSecondFactor
+		}
+		return nil
+	}
+
+	if req.RecoveryCode != "" {
+		raw := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(req.RecoveryCode), "-", ""))
+		codes, err := repositories.GetUnusedRecoveryCodes(db, userID)
+		if err != nil {
+			return err
+		}
+		for _, code := range codes {
+			if bcrypt.CompareHashAndPassword([]byte(code.Hash), []byte(raw)) != nil {
+				continue
+			}
+			used, err := repositories.UseRecoveryCode(db, code.ID)
+			if err != nil {
+				return err
+			}
+			if used {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidSecondFactor
+}
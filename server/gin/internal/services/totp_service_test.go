@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//✅ What This Tests
+//- TOTPCode(): RFC 6238 SHA1 test vectors, truncated to 6 digits
+//- MatchTOTP(): clock skew window and replay of a used step
+//- GenerateRecoveryCodes(): codes match their hashes without the dash
+
+// rfcSecret is the RFC 6238 SHA1 key "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFCVectors(t *testing.T) {
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		code, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "T=%d", unix)
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := TOTPStep(now)
+	previous, _ := TOTPCode(rfcSecret, step-1)
+	tooOld, _ := TOTPCode(rfcSecret, step-2)
+
+	matched, ok := MatchTOTP(rfcSecret, "081 804", now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+
+	// One step of drift is accepted, two are not
+	_, ok = MatchTOTP(rfcSecret, previous, now, 0)
+	assert.True(t, ok)
+	_, ok = MatchTOTP(rfcSecret, tooOld, now, 0)
+	assert.False(t, ok)
+
+	// A step already used can't be replayed
+	_, ok = MatchTOTP(rfcSecret, "081804", now, step)
+	assert.False(t, ok)
+	_, ok = MatchTOTP(rfcSecret, "12345", now, 0)
+	assert.False(t, ok)
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes()
+	assert.NoError(t, err)
+	assert.Len(t, codes, RecoveryCodeCount)
+	assert.Len(t, hashes, RecoveryCodeCount)
+	assert.Len(t, codes[0], 17)
+	raw := strings.ReplaceAll(codes[0], "-", "")
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashes[0]), []byte(raw)))
+}
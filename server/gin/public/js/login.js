@@ -3,6 +3,12 @@ function login(event) {
     if (event) event.preventDefault();
     console.log("login function running")
 
+    // Second step for accounts with two-factor authentication
+    if ($('#totp-group').length && !$('#totp-group').prop('hidden')) {
+        loginTOTP();
+        return;
+    }
+
     let username = $('#username').val().trim();
     let password = $('#password').val().trim();
 
@@ -33,7 +39,12 @@ function login(event) {
         .done(function (data) {
             //should redirect if correct username and password or send error message if not
             console.log("Success response:", data);
-            if (data.message) {
+            if (data.mfaRequired) {
+                // Password was right, ask for the authenticator code
+                $('#totp-group').prop('hidden', false);
+                $('#totp-code').focus();
+                $('#rxData').text("Enter the code from your authenticator app or a recovery code.").css("color", "");
+            } else if (data.message) {
                 localStorage.setItem("message", data.message);
                 //add 500ms delay to change of page after pressing submit
                 setTimeout(() => {
@@ -61,6 +72,8 @@ function login(event) {
                 } else {
                     errorMsg = "Invalid username or password";
                 }
+            } else if (jqXHR.status === 429 && jqXHR.responseJSON) {
+                errorMsg = jqXHR.responseJSON.error;
             } else if (jqXHR.status === 0) {
                 errorMsg = "Server not responding. Is it running?";
             } else {
@@ -71,9 +84,53 @@ function login(event) {
         });
 }
 
+function loginTOTP() {
+    let code = $('#totp-code').val().trim();
+    if (!code) {
+        $('#error-message').text("Please enter your authentication code.");
+        return;
+    }
+
+    // Recovery codes are the only ones with letters
+    let txdata = /^\d[\d ]*$/.test(code) ? { code: code } : { recoveryCode: code };
+
+    $.ajax({
+        url: '/login/totp',
+        method: 'POST',
+        contentType: 'application/json',
+        data: JSON.stringify(txdata),
+        dataType: 'json',
+        xhrFields: {
+            withCredentials: true
+        }
+    })
+        .done(function (data) {
+            localStorage.setItem("message", data.message);
+            setTimeout(() => {
+                window.location.href = "/dashboard";
+            }, 500);
+        })
+        .fail(function (jqXHR) {
+            let errorMsg;
+            if (jqXHR.status === 401) {
+                // The password step expired, start over
+                $('#totp-group').prop('hidden', true);
+                $('#totp-code').val("");
+                errorMsg = "Your login has expired. Please login again.";
+            } else if ((jqXHR.status === 400 || jqXHR.status === 429) && jqXHR.responseJSON) {
+                errorMsg = jqXHR.responseJSON.error;
+            } else if (jqXHR.status === 0) {
+                errorMsg = "Server not responding. Is it running?";
+            } else {
+                errorMsg = "Unexpected error occurred.";
+            }
+            $('#rxData').text(errorMsg).css("color", "red");
+        });
+}
+
 $(function () {
     console.log("running here too!");
-    $('#username, #password').on('input', function () {
+    $('#username, #password, #totp-code').on('input', function () {
         $('#error-message').text("");  // Clear the error message
     });
 
@@ -94,4 +151,5 @@ $(function () {
 
 if (typeof module !== 'undefined' && typeof module.exports !== 'undefined') {
     module.exports = login;
+    module.exports.loginTOTP = loginTOTP;
 }
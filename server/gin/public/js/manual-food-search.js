@@ -188,6 +188,7 @@ document.addEventListener("click",  function (e) {
       mealType: "history",
       ingredients: [{
         name: foodItem.name,
+        foodCode: foodItem.foodCode,
         grams: foodItem.grams,
         calories: foodItem.calories,
         protein: foodItem.protein,
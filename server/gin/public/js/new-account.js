@@ -4,6 +4,8 @@ document.getElementById('new-account-form').addEventListener('submit',  function
     const firstName = document.getElementById('first-name').value.trim();
     const lastName = document.getElementById('last-name').value.trim();
     const username = document.getElementById('username').value.trim();
+    const emailInput = document.getElementById('email');
+    const email = emailInput ? emailInput.value.trim() : '';
     const password = document.getElementById('password').value;
     const confirmPassword = document.getElementById('confirm-password').value;
     const errorMessage = document.getElementById('error-message');
@@ -34,7 +36,8 @@ document.getElementById('new-account-form').addEventListener('submit',  function
                     firstname: firstName,
                     lastname: lastName,
                     username: username,
-                    inputpassword: password
+                    inputpassword: password,
+                    email: email
                 })
             });
 
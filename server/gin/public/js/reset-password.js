@@ -0,0 +1,90 @@
+// The emailed link carries the token as ?token=
+function resetToken() {
+    return new URLSearchParams(window.location.search).get('token');
+}
+
+async function forgotPassword(event) {
+    if (event) event.preventDefault();
+    const email = document.getElementById('email').value.trim();
+    const errorMessage = document.getElementById('error-message');
+    const message = document.getElementById('message');
+    errorMessage.textContent = '';
+    message.textContent = '';
+
+    if (!email) {
+        errorMessage.textContent = 'Please enter your email.';
+        return;
+    }
+
+    try {
+        const response = await fetch('/forgot-password', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ email: email })
+        });
+        const result = await response.json();
+        if (response.ok) {
+            message.textContent = result.message;
+        } else {
+            errorMessage.textContent = result.error;
+        }
+    } catch (error) {
+        errorMessage.textContent = 'Network error. Please check your connection and try again.';
+    }
+}
+
+async function resetPassword(event) {
+    if (event) event.preventDefault();
+    const password = document.getElementById('password').value;
+    const confirmPassword = document.getElementById('confirm-password').value;
+    const errorMessage = document.getElementById('error-message');
+    const message = document.getElementById('message');
+    errorMessage.textContent = '';
+    message.textContent = '';
+
+    if (password.length < 6) {
+        errorMessage.textContent = 'Password must be at least 6 characters long.';
+        return;
+    }
+    if (password !== confirmPassword) {
+        errorMessage.textContent = 'Passwords do not match.';
+        return;
+    }
+
+    try {
+        const response = await fetch('/reset-password', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ token: resetToken(), newPassword: password })
+        });
+        const result = await response.json();
+        if (response.ok) {
+            message.textContent = result.message;
+            document.getElementById('reset-password-form').hidden = true;
+        } else if (response.status === 500) {
+            errorMessage.textContent = 'Server error. Please try again later.';
+        } else {
+            errorMessage.textContent = result.error;
+        }
+    } catch (error) {
+        errorMessage.textContent = 'Network error. Please check your connection and try again.';
+    }
+}
+
+function initResetPassword() {
+    const forgotForm = document.getElementById('forgot-password-form');
+    const resetForm = document.getElementById('reset-password-form');
+    if (resetToken()) {
+        forgotForm.hidden = true;
+        resetForm.hidden = false;
+    }
+    forgotForm.addEventListener('submit', forgotPassword);
+    resetForm.addEventListener('submit', resetPassword);
+}
+
+document.addEventListener('DOMContentLoaded', initResetPassword);
+
+// Export for testing
+if (typeof module !== 'undefined' && typeof module.exports !== 'undefined') {
+    module.exports = { forgotPassword, resetPassword, initResetPassword };
+}
@@ -201,9 +201,19 @@ async function loadSavedMeals() {
   }
 }
 
+// fetchMeals Returns every favorite, following the listing's pages
 async function fetchMeals() {
-  const res = await fetch("/dashboard/api/user-meal-history", { credentials: "include" });
-  return res.json();
+  let meals = [];
+  let cursor = "";
+  do {
+    const params = new URLSearchParams({ limit: "200" });
+    if (cursor) params.set("cursor", cursor);
+    const res = await fetch(`/dashboard/api/user-meal-history?${params}`, { credentials: "include" });
+    const page = await res.json();
+    meals = meals.concat(page.meals || []);
+    cursor = page.nextCursor || "";
+  } while (cursor);
+  return meals;
 }
 
 function groupMeals(meals) {
@@ -239,8 +249,8 @@ function generateMealsHTML(grouped) {
           <tr class="meal-subheader">
             <th colspan="7">
               <strong>${meal.mealName}</strong> <small>(${mealTime})</small>
-              <button class="log-again-btn" data-name="${meal.mealName}" data-ingredients='${JSON.stringify(meal.ingredients)}'>Log This Meal Again</button>
-              <button class="delete-meal-btn" data-mealname="${meal.mealName}" data-mealtime="${meal.time}">🗑 Delete</button>
+              <button class="log-again-btn" data-id="${meal.id}" data-ingredients='${JSON.stringify(meal.ingredients)}'>Log This Meal Again</button>
+              <button class="delete-meal-btn" data-mealid="${meal.id}" data-mealname="${meal.mealName}" data-mealtime="${meal.time}">🗑 Delete</button>
             </th>
           </tr>
           <tr>
@@ -279,9 +289,9 @@ function generateMealsHTML(grouped) {
 function setupDeleteButtons() {
   document.querySelectorAll(".delete-meal-btn").forEach(btn => {
     btn.addEventListener("click", async () => {
-      const { mealname: mealName, mealtime: mealTime } = btn.dataset;
-      if (confirm(`Delete all entries for "${mealName}" at ${new Date(mealTime).toLocaleString()}?`)) {
-        await deleteMealByNameAndTime(mealName, mealTime);
+      const { mealid: mealId, mealname: mealName, mealtime: mealTime } = btn.dataset;
+      if (confirm(`Delete "${mealName}" at ${new Date(mealTime).toLocaleString()}?`)) {
+        await deleteMealByID(mealId);
         await loadSavedMeals();
       }
     });
@@ -292,7 +302,6 @@ function setupLogAgainButtons() {
   document.querySelectorAll(".log-again-btn").forEach(btn => {
     btn.addEventListener("click", async () => {
       try {
-        const mealName = btn.dataset.name;
         const ingredients = JSON.parse(btn.dataset.ingredients);
 
         const portionInput = prompt("How many grams did you eat from this meal?");
@@ -303,27 +312,14 @@ function setupLogAgainButtons() {
           return;
         }
 
+        // The server scales the favorite and recomputes its nutrients
         const totalGrams = ingredients.reduce((sum, i) => sum + (i.grams || 0), 0);
-        const scaleFactor = portionGrams / totalGrams;
-
-        const scaledIngredients = ingredients.map(i => ({
-          name: i.name,
-          grams: Math.round(i.grams * scaleFactor),
-          calories: Math.round(i.calories * scaleFactor),
-          protein: Math.round(i.protein * scaleFactor),
-          carbs: Math.round(i.carbs * scaleFactor),
-          phosphorus: Math.round(i.phosphorus * scaleFactor),
-          potassium: Math.round(i.potassium * scaleFactor)
-        }));
-
         const payload = {
-          mealName,
           time: new Date().toISOString(),
-          mealType: "history",
-          ingredients: scaledIngredients
+          servings: totalGrams > 0 ? portionGrams / totalGrams : 1
         };
 
-        const res = await fetch("/dashboard/api/user-meal-history", {
+        const res = await fetch(`/dashboard/api/favorites/${btn.dataset.id}/log`, {
           method: "POST",
           credentials: "include",
           headers: { "Content-Type": "application/json" },
@@ -349,13 +345,11 @@ function setupLogAgainButtons() {
 }
 
 
-async function deleteMealByNameAndTime(mealName, time) {
+async function deleteMealByID(mealId) {
   try {
-    const res = await fetch("/dashboard/user-meal-history", {
+    const res = await fetch(`/dashboard/api/meals/${mealId}`, {
       method: "DELETE",
-      credentials: "include",
-      headers: { "Content-Type": "application/json" },
-      body: JSON.stringify({ mealName, time })
+      credentials: "include"
     });
 
     if (!res.ok) {
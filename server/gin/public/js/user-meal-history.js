@@ -108,12 +108,20 @@ window.onload = function () {
 
 };
 
-async function loadLoggedMeals() {
-  try {
-    const res = await fetch("/dashboard/api/user-logged-meals", { credentials: "include" });
-    const meals = await res.json();
+// Meals of the pages loaded so far, and the cursor of the next page
+let loggedMeals = [];
+let loggedMealsCursor = "";
 
-    renderLoggedMeals(meals);
+async function loadLoggedMeals(cursor = "") {
+  try {
+    const params = new URLSearchParams();
+    if (cursor) params.set("cursor", cursor);
+    const res = await fetch(`/dashboard/api/user-logged-meals?${params}`, { credentials: "include" });
+    const page = await res.json();
+
+    loggedMeals = cursor ? loggedMeals.concat(page.meals || []) : (page.meals || []);
+    loggedMealsCursor = page.nextCursor || "";
+    renderLoggedMeals(loggedMeals);
   } catch (err) {
     console.error("❌ Failed to fetch meal history logs:", err);
     document.getElementById("loggedMeals").innerHTML = "<p>Could not load meal logs.</p>";
@@ -156,7 +164,7 @@ function renderLoggedMeals(meals) {
         <div class="meal-header">
           <div class="meal-meta">
             <h4>${meal.mealName}</h4>
-            <small>${mealTime}</small>
+            <small>${meal.slot ? `${meal.slot[0].toUpperCase()}${meal.slot.slice(1)} · ` : ""}${mealTime}</small>
           </div>
         </div>
         <table>
@@ -200,6 +208,14 @@ function renderLoggedMeals(meals) {
     </div>`;
   }
 
+  if (loggedMealsCursor) {
+    html += `<button id="loadMoreMeals" class="submit-button">Load more</button>`;
+  }
   container.innerHTML = html;
+
+  const more = document.getElementById("loadMoreMeals");
+  if (more) {
+    more.addEventListener("click", () => loadLoggedMeals(loggedMealsCursor));
+  }
 }
 
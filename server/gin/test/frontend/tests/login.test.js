@@ -233,3 +233,52 @@ test("shows fallback message when no message received from testutils", () => {
     expect($("#rxData").text()).toBe("Login failed: No message received.");
 });
 
+
+test("asks for the authentication code when two-factor is enabled", () => {
+    document.body.innerHTML += `<div id="totp-group" hidden><input type="text" id="totp-code" /></div>`;
+    $("#username").val("joseph");
+    $("#password").val("pass");
+
+    $.ajax = jest.fn(() => ({
+        done: function (cb) {
+            cb({ mfaRequired: true });
+            return this;
+        },
+        fail: function () { return this; }
+    }));
+
+    login({ preventDefault: () => {} });
+
+    expect($("#totp-group").prop("hidden")).toBe(false);
+    expect($("#rxData").text()).toMatch(/authenticator app/);
+
+    // The next submit sends the code instead of the password
+    $("#totp-code").val("123 456");
+    login({ preventDefault: () => {} });
+
+    expect($.ajax).toHaveBeenLastCalledWith(expect.objectContaining({
+        url: '/login/totp',
+        data: JSON.stringify({ code: "123 456" })
+    }));
+});
+
+test("sends a recovery code and starts over when the login expired", () => {
+    document.body.innerHTML += `<div id="totp-group"><input type="text" id="totp-code" /></div>`;
+    $("#totp-code").val("abcdefgh-ijklmnop");
+
+    $.ajax = jest.fn(() => ({
+        done: function () { return this; },
+        fail: function (cb) {
+            cb({ status: 401, responseJSON: { error: "expired" } });
+            return this;
+        }
+    }));
+
+    login.loginTOTP();
+
+    expect($.ajax).toHaveBeenCalledWith(expect.objectContaining({
+        data: JSON.stringify({ recoveryCode: "abcdefgh-ijklmnop" })
+    }));
+    expect($("#totp-group").prop("hidden")).toBe(true);
+    expect($("#rxData").text()).toBe("Your login has expired. Please login again.");
+});
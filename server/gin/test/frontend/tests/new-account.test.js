@@ -57,7 +57,8 @@ test("successful form submission sends POST and redirects to /", async () => {
                 firstname: "John",
                 lastname: "Doe",
                 username: "johndoe",
-                inputpassword: "abcdef"
+                inputpassword: "abcdef",
+                email: ""
             })
         })
     );
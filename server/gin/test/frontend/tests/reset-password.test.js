@@ -0,0 +1,78 @@
+/**
+ * @jest-environment jsdom
+ *
+ * 🔍 TEST SUMMARY
+ * -------------------------------
+ * ✅ The email form is shown without a token, the password form with one
+ * ✅ forgotPassword() posts the email and shows the response message
+ * ✅ resetPassword() checks the passwords match before posting
+ * ✅ resetPassword() posts the token from the link and shows server errors
+ */
+
+const { forgotPassword, resetPassword, initResetPassword } = require("../../../public/js/reset-password.js");
+
+beforeEach(() => {
+    document.body.innerHTML = `
+    <form id="forgot-password-form"><input id="email" /></form>
+    <form id="reset-password-form" hidden>
+      <input id="password" />
+      <input id="confirm-password" />
+    </form>
+    <p id="error-message"></p>
+    <p id="message"></p>
+  `;
+    global.fetch = jest.fn();
+    window.history.replaceState({}, "", "/reset-password");
+});
+
+test("shows the password form when opened from the emailed link", () => {
+    window.history.replaceState({}, "", "/reset-password?token=abc");
+    initResetPassword();
+
+    expect(document.getElementById("forgot-password-form").hidden).toBe(true);
+    expect(document.getElementById("reset-password-form").hidden).toBe(false);
+});
+
+test("forgotPassword posts the email", async () => {
+    document.getElementById("email").value = " ada@example.com ";
+    global.fetch.mockResolvedValueOnce({
+        ok: true,
+        json: async () => ({ message: "If an account uses that email, a reset link is on its way." })
+    });
+
+    await forgotPassword({ preventDefault: jest.fn() });
+
+    expect(global.fetch).toHaveBeenCalledWith("/forgot-password", expect.objectContaining({
+        method: "POST",
+        body: JSON.stringify({ email: "ada@example.com" })
+    }));
+    expect(document.getElementById("message").textContent).toMatch(/reset link/);
+});
+
+test("resetPassword rejects mismatched passwords", async () => {
+    document.getElementById("password").value = "abcdef";
+    document.getElementById("confirm-password").value = "abcdeg";
+
+    await resetPassword({ preventDefault: jest.fn() });
+
+    expect(global.fetch).not.toHaveBeenCalled();
+    expect(document.getElementById("error-message").textContent).toBe("Passwords do not match.");
+});
+
+test("resetPassword posts the token and shows an expired link error", async () => {
+    window.history.replaceState({}, "", "/reset-password?token=abc");
+    document.getElementById("password").value = "abcdef";
+    document.getElementById("confirm-password").value = "abcdef";
+    global.fetch.mockResolvedValueOnce({
+        ok: false,
+        status: 400,
+        json: async () => ({ error: "This reset link is invalid or has expired. Please request a new one." })
+    });
+
+    await resetPassword({ preventDefault: jest.fn() });
+
+    expect(global.fetch).toHaveBeenCalledWith("/reset-password", expect.objectContaining({
+        body: JSON.stringify({ token: "abc", newPassword: "abcdef" })
+    }));
+    expect(document.getElementById("error-message").textContent).toMatch(/expired/);
+});
@@ -0,0 +1,22 @@
+package testutils
+
+import (
+	"testing"
+
+	"github.com/kimsh02/kay-phos/server/gin/internal/services"
+)
+
+// UseTestKeyRing Signs and verifies tokens with a fixed HS256 key for the
+// rest of the test
+func UseTestKeyRing(t *testing.T) {
+	key, err := services.NewHMACKey("test", []byte("testsecret-testsecret-testsecret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := services.NewKeyRing(key.ID, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	services.SetKeyRing(keys)
+	t.Cleanup(func() { services.SetKeyRing(nil) })
+}
@@ -1,6 +1,10 @@
 package testutils
 
 import (
+	"reflect"
+	"strings"
+	"time"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/mock"
@@ -39,3 +43,53 @@ func (m *MockRows) Values() ([]any, error)                       { return nil, n
 func (m *MockRows) RawValues() [][]byte                          { return nil }
 func (m *MockRows) Conn() *pgx.Conn                              { return nil }
 func (m *MockRows) RawValuesBytes() [][]byte                     { return nil }
+
+// MockRow is a pgx.Row whose Scan copies Values into dest in order, or
+// returns Err without filling dest
+type MockRow struct {
+	Values []any
+	Err    error
+}
+
+func (m *MockRow) Scan(dest ...any) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	for i, v := range m.Values {
+		if i < len(dest) && v != nil {
+			reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(v))
+		}
+	}
+	return nil
+}
+
+// ResultRows is a pgx.Rows that yields each entry of Rows in order, scanning
+// it like a MockRow. Failure is the error reported once the rows are read,
+// and Closed records whether they were closed
+type ResultRows struct {
+	MockRows
+	Rows    [][]any
+	Failure error
+	Closed  bool
+	index   int
+}
+
+func (r *ResultRows) Close()     { r.Closed = true }
+func (r *ResultRows) Err() error { return r.Failure }
+
+func (r *ResultRows) Next() bool {
+	r.index++
+	return r.index <= len(r.Rows)
+}
+
+func (r *ResultRows) Scan(dest ...any) error {
+	return (&MockRow{Values: r.Rows[r.index-1]}).Scan(dest...)
+}
+
+// MockAuditLog Lets the audit log insert succeed. Register it before any
+// catch-all QueryRow, the first match wins
+func MockAuditLog(m *MockDB) {
+	m.On("QueryRow", mock.Anything, mock.MatchedBy(func(sql string) bool {
+		return strings.Contains(sql, "INSERT INTO audit_events")
+	}), mock.Anything).Return(&MockRow{Values: []any{int64(1), time.Now()}})
+}